@@ -0,0 +1,67 @@
+package otils
+
+import (
+	"encoding/base64"
+	"net/http"
+	"strings"
+)
+
+// ParseBasicAuth returns the username and password from the Basic
+// Authorization header of r. Unlike r.BasicAuth it tolerates surrounding
+// whitespace and any casing of the "Basic" scheme, as sent by some clients.
+// ok is false if the header is missing, uses another scheme or is malformed.
+func ParseBasicAuth(r *http.Request) (username, password string, ok bool) {
+	payload, ok := authCredentials(r.Header.Get("Authorization"), "Basic")
+	if !ok {
+		return "", "", false
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		return "", "", false
+	}
+	credentials := string(decoded)
+	sep := strings.IndexByte(credentials, ':')
+	if sep < 0 {
+		return "", "", false
+	}
+	return credentials[:sep], credentials[sep+1:], true
+}
+
+// ExtractToken returns the first token that r carries, looking in order at a
+// Bearer Authorization header, then at the headers named by headerNames, e.g.
+// "X-API-Key", and lastly at the query keys queryKeys, e.g. "access_token".
+// The second value is the source of the token, which is "header:" followed by
+// the canonical header name or "query:" followed by the key, for example
+// "header:Authorization". Both are empty if no token was found.
+func ExtractToken(r *http.Request, headerNames []string, queryKeys []string) (token, source string) {
+	if token, _ := authCredentials(r.Header.Get("Authorization"), "Bearer"); token != "" {
+		return token, "header:Authorization"
+	}
+	for _, name := range headerNames {
+		if token := strings.TrimSpace(r.Header.Get(name)); token != "" {
+			return token, "header:" + http.CanonicalHeaderKey(name)
+		}
+	}
+	if len(queryKeys) > 0 {
+		query := r.URL.Query()
+		for _, key := range queryKeys {
+			if token := strings.TrimSpace(query.Get(key)); token != "" {
+				return token, "query:" + key
+			}
+		}
+	}
+	return "", ""
+}
+
+// authCredentials returns the credentials that follow scheme in an
+// Authorization header value, accepting any casing of the scheme and
+// surrounding whitespace. ok is false if header uses another scheme.
+func authCredentials(header, scheme string) (credentials string, ok bool) {
+	header = strings.TrimSpace(header)
+	i := strings.IndexAny(header, " \t")
+	if i < 0 || !strings.EqualFold(header[:i], scheme) {
+		return "", false
+	}
+	return strings.TrimSpace(header[i:]), true
+}
@@ -0,0 +1,119 @@
+package otils
+
+import (
+	"encoding/base64"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseBasicAuth(t *testing.T) {
+	encode := func(s string) string {
+		return base64.StdEncoding.EncodeToString([]byte(s))
+	}
+	tests := []struct {
+		name         string
+		header       string
+		wantUsername string
+		wantPassword string
+		wantOK       bool
+	}{
+		{"valid", "Basic " + encode("ada:s3cret"), "ada", "s3cret", true},
+		{"lowercase scheme", "basic " + encode("ada:s3cret"), "ada", "s3cret", true},
+		{"uppercase scheme and whitespace", "  BASIC   " + encode("ada:s3:cret") + " ", "ada", "s3:cret", true},
+		{"empty password", "Basic " + encode("ada:"), "ada", "", true},
+		{"malformed base64", "Basic not*base64", "", "", false},
+		{"missing colon", "Basic " + encode("ada"), "", "", false},
+		{"other scheme", "Bearer " + encode("ada:s3cret"), "", "", false},
+		{"scheme only", "Basic", "", "", false},
+		{"missing", "", "", "", false},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			req := httptest.NewRequest("GET", "/", nil)
+			if tc.header != "" {
+				req.Header.Set("Authorization", tc.header)
+			}
+			username, password, ok := ParseBasicAuth(req)
+			if ok != tc.wantOK || username != tc.wantUsername || password != tc.wantPassword {
+				t.Errorf("unexpected result, want: (%q, %q, %v), got: (%q, %q, %v)",
+					tc.wantUsername, tc.wantPassword, tc.wantOK, username, password, ok)
+			}
+		})
+	}
+}
+
+func TestExtractToken(t *testing.T) {
+	headerNames := []string{"x-api-key", "X-Auth-Token"}
+	queryKeys := []string{"access_token", "api_key"}
+	tests := []struct {
+		name       string
+		target     string
+		headers    map[string]string
+		wantToken  string
+		wantSource string
+	}{
+		{
+			name:       "bearer wins",
+			target:     "/?access_token=q1",
+			headers:    map[string]string{"Authorization": "Bearer b1", "X-Api-Key": "h1"},
+			wantToken:  "b1",
+			wantSource: "header:Authorization",
+		},
+		{
+			name:       "lowercase bearer",
+			target:     "/",
+			headers:    map[string]string{"Authorization": "  bearer   b1 "},
+			wantToken:  "b1",
+			wantSource: "header:Authorization",
+		},
+		{
+			name:       "first custom header wins",
+			target:     "/?access_token=q1",
+			headers:    map[string]string{"Authorization": "Basic dXNlcjpwYXNz", "X-Auth-Token": "h2", "X-Api-Key": "h1"},
+			wantToken:  "h1",
+			wantSource: "header:X-Api-Key",
+		},
+		{
+			name:       "second custom header",
+			target:     "/?access_token=q1",
+			headers:    map[string]string{"X-Auth-Token": "h2"},
+			wantToken:  "h2",
+			wantSource: "header:X-Auth-Token",
+		},
+		{
+			name:       "first query key wins",
+			target:     "/?api_key=q2&access_token=q1",
+			wantToken:  "q1",
+			wantSource: "query:access_token",
+		},
+		{
+			name:       "second query key",
+			target:     "/?api_key=q2&access_token=",
+			wantToken:  "q2",
+			wantSource: "query:api_key",
+		},
+		{
+			name:    "none",
+			target:  "/?token=q3",
+			headers: map[string]string{"Authorization": "Bearer "},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			req := httptest.NewRequest("GET", tc.target, nil)
+			for key, value := range tc.headers {
+				req.Header.Set(key, value)
+			}
+			token, source := ExtractToken(req, headerNames, queryKeys)
+			if token != tc.wantToken || source != tc.wantSource {
+				t.Errorf("unexpected result, want: (%q, %q), got: (%q, %q)", tc.wantToken, tc.wantSource, token, source)
+			}
+		})
+	}
+}
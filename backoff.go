@@ -0,0 +1,54 @@
+package otils
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// BackoffWithJitter returns how long to wait before retrying after the
+// given number of failed attempts, starting from 0. It uses exponential
+// backoff with full jitter: a random duration between 0 and base*2^attempt,
+// with the latter capped at max. Spreading retries out like that keeps
+// clients that failed together from retrying in lockstep.
+func BackoffWithJitter(attempt int, base, max time.Duration) time.Duration {
+	return backoffWithJitter(attempt, base, max, rand.Int63n)
+}
+
+// backoffWithJitter is BackoffWithJitter with its source of randomness
+// injected. int63n must return a value in [0, n) like rand.Int63n.
+func backoffWithJitter(attempt int, base, max time.Duration, int63n func(n int64) int64) time.Duration {
+	ceiling := exponentialBackoff(attempt, base, max)
+	if ceiling <= 0 {
+		return 0
+	}
+	n := int64(ceiling)
+	if n < math.MaxInt64 {
+		// Include the ceiling itself, which a max of math.MaxInt64,
+		// commonly used for no cap, can't be without overflowing.
+		n++
+	}
+	return time.Duration(int63n(n))
+}
+
+// exponentialBackoff returns base*2^attempt capped at max,
+// taking care not to overflow for large numbers of attempts.
+func exponentialBackoff(attempt int, base, max time.Duration) time.Duration {
+	if base <= 0 || max <= 0 {
+		return 0
+	}
+	if attempt < 0 {
+		attempt = 0
+	}
+	backoff := base
+	for i := 0; i < attempt; i++ {
+		if backoff > max/2 {
+			return max
+		}
+		backoff *= 2
+	}
+	if backoff > max {
+		return max
+	}
+	return backoff
+}
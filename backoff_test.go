@@ -0,0 +1,67 @@
+package otils
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// noJitter makes backoffWithJitter return the full backoff deterministically.
+func noJitter(n int64) int64 { return n - 1 }
+
+func TestBackoffWithJitter(t *testing.T) {
+	tests := []struct {
+		name     string
+		attempt  int
+		base     time.Duration
+		max      time.Duration
+		expected time.Duration
+	}{
+		{"first attempt", 0, 100 * time.Millisecond, 10 * time.Second, 100 * time.Millisecond},
+		{"second attempt", 1, 100 * time.Millisecond, 10 * time.Second, 200 * time.Millisecond},
+		{"fifth attempt", 4, 100 * time.Millisecond, 10 * time.Second, 1600 * time.Millisecond},
+		{"capped", 10, 100 * time.Millisecond, 10 * time.Second, 10 * time.Second},
+		{"no overflow", math.MaxInt32, time.Second, time.Hour, time.Hour},
+		{"negative attempt", -1, time.Second, time.Hour, time.Second},
+		{"base above max", 0, time.Minute, time.Second, time.Second},
+		{"zero base", 3, 0, time.Second, 0},
+		{"uncapped", 100, time.Second, math.MaxInt64, math.MaxInt64 - 1},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if got := backoffWithJitter(tc.attempt, tc.base, tc.max, noJitter); got != tc.expected {
+				t.Errorf("unexpected result, want: %v, got: %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestBackoffWithJitterGrowsAndIsCapped(t *testing.T) {
+	base, max := 50*time.Millisecond, 5*time.Second
+	prev := time.Duration(0)
+	for attempt := 0; attempt < 20; attempt++ {
+		got := backoffWithJitter(attempt, base, max, noJitter)
+		if got < prev {
+			t.Errorf("attempt %d: backoff shrank from %v to %v", attempt, prev, got)
+		}
+		prev = got
+	}
+	if prev != max {
+		t.Errorf("expected the backoff to reach max, want: %v, got: %v", max, prev)
+	}
+
+	// With real jitter the result stays within [0, max].
+	for attempt := 0; attempt < 1000; attempt++ {
+		if got := BackoffWithJitter(attempt%30, base, max); got < 0 || got > max {
+			t.Fatalf("attempt %d: backoff %v out of [0, %v]", attempt, got, max)
+		}
+	}
+
+	// A max of math.MaxInt64 doesn't overflow the range of the jitter.
+	if got := BackoffWithJitter(100, base, math.MaxInt64); got < 0 {
+		t.Errorf("uncapped backoff %v is negative", got)
+	}
+}
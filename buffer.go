@@ -0,0 +1,102 @@
+package otils
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+)
+
+// BufferedResponseWriter is an http.ResponseWriter that buffers the status,
+// headers and body written to it instead of sending them, so that middleware
+// can inspect or modify a complete response before committing it with Flush.
+// Its zero value is ready to use.
+type BufferedResponseWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+var _ http.ResponseWriter = (*BufferedResponseWriter)(nil)
+
+// Header returns the buffered headers, which can be modified until Flush.
+func (brw *BufferedResponseWriter) Header() http.Header {
+	if brw.header == nil {
+		brw.header = make(http.Header)
+	}
+	return brw.header
+}
+
+// WriteHeader buffers the status code. Like with net/http,
+// only the first call has any effect.
+func (brw *BufferedResponseWriter) WriteHeader(code int) {
+	if brw.status == 0 {
+		brw.status = code
+	}
+}
+
+// Write appends b to the buffered body.
+func (brw *BufferedResponseWriter) Write(b []byte) (int, error) {
+	if brw.status == 0 {
+		brw.status = http.StatusOK
+	}
+	return brw.body.Write(b)
+}
+
+// Status returns the buffered status code, which is
+// 200 OK if none was written like with net/http.
+func (brw *BufferedResponseWriter) Status() int {
+	if brw.status == 0 {
+		return http.StatusOK
+	}
+	return brw.status
+}
+
+// SetStatus replaces the buffered status code.
+func (brw *BufferedResponseWriter) SetStatus(code int) {
+	brw.status = code
+}
+
+// Body returns the buffered body. It aliases the buffer so
+// it is only valid until the next modification of the body.
+func (brw *BufferedResponseWriter) Body() []byte {
+	return brw.body.Bytes()
+}
+
+// SetBody replaces the buffered body with a copy of body.
+func (brw *BufferedResponseWriter) SetBody(body []byte) {
+	brw.body.Reset()
+	brw.body.Write(body)
+}
+
+// Flush sends the buffered status, headers and body to w. The
+// Content-Length header is set to the length of the buffered body
+// since a modified body would invalidate any that was written.
+func (brw *BufferedResponseWriter) Flush(w http.ResponseWriter) error {
+	header := w.Header()
+	for key, values := range brw.header {
+		header[key] = append([]string(nil), values...)
+	}
+	status := brw.Status()
+	if bodyAllowedForStatus(status) {
+		header.Set("Content-Length", strconv.Itoa(brw.body.Len()))
+	}
+	w.WriteHeader(status)
+	if brw.body.Len() == 0 || !bodyAllowedForStatus(status) {
+		return nil
+	}
+	_, err := w.Write(brw.body.Bytes())
+	return err
+}
+
+// bodyAllowedForStatus reports whether a response with
+// the given status code is permitted to have a body.
+func bodyAllowedForStatus(status int) bool {
+	switch {
+	case status >= 100 && status <= 199:
+		return false
+	case status == http.StatusNoContent, status == http.StatusNotModified:
+		return false
+	default:
+		return true
+	}
+}
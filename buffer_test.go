@@ -0,0 +1,95 @@
+package otils
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func TestBufferedResponseWriter(t *testing.T) {
+	handler := func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("Content-Type", "text/plain")
+		rw.Header().Set("Content-Length", "11")
+		rw.WriteHeader(http.StatusCreated)
+		rw.Write([]byte("hello "))
+		rw.Write([]byte("world"))
+	}
+
+	tests := []struct {
+		name     string
+		modify   func(brw *BufferedResponseWriter)
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:     "unchanged",
+			wantCode: http.StatusCreated,
+			wantBody: "hello world",
+		},
+		{
+			name: "modified",
+			modify: func(brw *BufferedResponseWriter) {
+				brw.SetBody(bytes.ToUpper(brw.Body()))
+				brw.SetBody(append(brw.Body(), '!'))
+				brw.Header().Set("X-Modified", "1")
+				brw.SetStatus(http.StatusAccepted)
+			},
+			wantCode: http.StatusAccepted,
+			wantBody: "HELLO WORLD!",
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			brw := new(BufferedResponseWriter)
+			handler(brw, httptest.NewRequest("GET", "/", nil))
+			if got := string(brw.Body()); got != "hello world" {
+				t.Fatalf("unexpected buffered body: %q", got)
+			}
+
+			rec := httptest.NewRecorder()
+			if tc.modify != nil {
+				tc.modify(brw)
+			}
+			if err := brw.Flush(rec); err != nil {
+				t.Fatal(err)
+			}
+			if rec.Code != tc.wantCode {
+				t.Errorf("unexpected status, want: %d, got: %d", tc.wantCode, rec.Code)
+			}
+			if got := rec.Body.String(); got != tc.wantBody {
+				t.Errorf("unexpected body, want: %q, got: %q", tc.wantBody, got)
+			}
+			if got, want := rec.Header().Get("Content-Length"), strconv.Itoa(len(tc.wantBody)); got != want {
+				t.Errorf("unexpected Content-Length, want: %q, got: %q", want, got)
+			}
+			if got := rec.Header().Get("Content-Type"); got != "text/plain" {
+				t.Errorf("unexpected Content-Type: %q", got)
+			}
+		})
+	}
+}
+
+func TestBufferedResponseWriterDefaults(t *testing.T) {
+	brw := new(BufferedResponseWriter)
+	if got := brw.Status(); got != http.StatusOK {
+		t.Errorf("unexpected default status, want: %d, got: %d", http.StatusOK, got)
+	}
+	brw.WriteHeader(http.StatusNoContent)
+	brw.WriteHeader(http.StatusTeapot)
+	if got := brw.Status(); got != http.StatusNoContent {
+		t.Errorf("expected the first status to win, want: %d, got: %d", http.StatusNoContent, got)
+	}
+
+	rec := httptest.NewRecorder()
+	if err := brw.Flush(rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusNoContent || rec.Header().Get("Content-Length") != "" {
+		t.Errorf("unexpected response: %d %v", rec.Code, rec.Header())
+	}
+}
@@ -0,0 +1,87 @@
+package otils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RequestCacheKey returns a stable hex digest that identifies a request for
+// the purposes of response caching. It combines the method, the cleaned
+// URL path, the query string sorted by key and the Accept header if present.
+// All other headers are deliberately excluded since they tend to be volatile.
+func RequestCacheKey(r *http.Request) string {
+	reqPath := "/"
+	if r.URL.Path != "" {
+		reqPath = path.Clean("/" + r.URL.Path)
+	}
+
+	parts := []string{
+		strings.ToUpper(r.Method),
+		reqPath,
+		// url.Values.Encode sorts the query by key.
+		r.URL.Query().Encode(),
+	}
+	if accept := r.Header.Get("Accept"); accept != "" {
+		parts = append(parts, accept)
+	}
+
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// CacheControl holds the directives of a Cache-Control header
+// that matter when deciding whether and how long to cache a response.
+type CacheControl struct {
+	// MaxAge is the value of the max-age directive, capped at
+	// 2^31 seconds, or 0 if it was absent or malformed.
+	MaxAge time.Duration
+
+	NoStore bool
+	NoCache bool
+	Private bool
+}
+
+// maxDeltaSeconds is the largest number of seconds that caches
+// are required to handle, per section 1.2.2 of RFC 9111.
+const maxDeltaSeconds = 1 << 31
+
+// ParseCacheControl parses the value of a Cache-Control header.
+// Directive names are case-insensitive and unknown ones are ignored.
+func ParseCacheControl(header string) CacheControl {
+	var cc CacheControl
+	for _, directive := range strings.Split(header, ",") {
+		splits := strings.SplitN(strings.TrimSpace(directive), "=", 2)
+		name := strings.ToLower(strings.TrimSpace(splits[0]))
+		switch name {
+		case "no-store":
+			cc.NoStore = true
+		case "no-cache":
+			cc.NoCache = true
+		case "private":
+			cc.Private = true
+		case "max-age":
+			if len(splits) != 2 {
+				continue
+			}
+			value := strings.Trim(strings.TrimSpace(splits[1]), `"`)
+			secs, err := strconv.ParseInt(value, 10, 64)
+			if numErr, ok := err.(*strconv.NumError); ok && numErr.Err == strconv.ErrRange && secs > 0 {
+				secs, err = maxDeltaSeconds, nil
+			}
+			if err == nil && secs >= 0 {
+				if secs > maxDeltaSeconds {
+					// As RFC 9111 requires, larger ages are taken as 2^31
+					// which also keeps them from overflowing a Duration.
+					secs = maxDeltaSeconds
+				}
+				cc.MaxAge = time.Duration(secs) * time.Second
+			}
+		}
+	}
+	return cc
+}
@@ -0,0 +1,83 @@
+package otils
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRequestCacheKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		a, b    string
+		headers map[string]string
+		same    bool
+	}{
+		{"query order", "/items?page=2&sort=asc", "/items?sort=asc&page=2", nil, true},
+		{"unclean path", "/items/./a/../?q=1", "/items?q=1", nil, true},
+		{"different path", "/items", "/users", nil, false},
+		{"different query", "/items?page=1", "/items?page=2", nil, false},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			ka := RequestCacheKey(httptest.NewRequest("GET", tc.a, nil))
+			kb := RequestCacheKey(httptest.NewRequest("GET", tc.b, nil))
+			if same := ka == kb; same != tc.same {
+				t.Errorf("unexpected result for %q and %q, want same: %v, got: %v", tc.a, tc.b, tc.same, same)
+			}
+		})
+	}
+}
+
+func TestRequestCacheKeyHeaders(t *testing.T) {
+	base := httptest.NewRequest("GET", "/items", nil)
+	baseKey := RequestCacheKey(base)
+
+	volatile := httptest.NewRequest("GET", "/items", nil)
+	volatile.Header.Set("Date", "Mon, 02 Jan 2006 15:04:05 GMT")
+	volatile.Header.Set("X-Request-Id", "abc")
+	if got := RequestCacheKey(volatile); got != baseKey {
+		t.Errorf("volatile headers should not change the key")
+	}
+
+	accept := httptest.NewRequest("GET", "/items", nil)
+	accept.Header.Set("Accept", "application/json")
+	if got := RequestCacheKey(accept); got == baseKey {
+		t.Errorf("the Accept header should change the key")
+	}
+
+	post := httptest.NewRequest("POST", "/items", nil)
+	if got := RequestCacheKey(post); got == baseKey {
+		t.Errorf("the method should change the key")
+	}
+}
+
+func TestParseCacheControl(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   string
+		expected CacheControl
+	}{
+		{"max-age and private", "max-age=60, private", CacheControl{MaxAge: 60 * time.Second, Private: true}},
+		{"no-store", "no-store", CacheControl{NoStore: true}},
+		{"empty", "", CacheControl{}},
+		{"mixed case and quoted", `Public, MAX-AGE="30", No-Cache`, CacheControl{MaxAge: 30 * time.Second, NoCache: true}},
+		{"malformed max-age", "max-age=soon, must-revalidate", CacheControl{}},
+		{"huge max-age", "max-age=99999999999", CacheControl{MaxAge: (1 << 31) * time.Second}},
+		{"out of range max-age", "max-age=99999999999999999999", CacheControl{MaxAge: (1 << 31) * time.Second}},
+		{"negative max-age", "max-age=-1", CacheControl{}},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if got := ParseCacheControl(tc.header); got != tc.expected {
+				t.Errorf("unexpected result, want: %+v, got: %+v", tc.expected, got)
+			}
+		})
+	}
+}
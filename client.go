@@ -0,0 +1,225 @@
+package otils
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GzipRequestBody replaces the body of req with its gzip compressed form,
+// setting the Content-Encoding and Content-Length headers and GetBody to
+// match. It does nothing if req has no body.
+func GzipRequestBody(req *http.Request) error {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil
+	}
+
+	buf := new(bytes.Buffer)
+	gw := gzip.NewWriter(buf)
+	_, err := io.Copy(gw, req.Body)
+	_ = req.Body.Close()
+	if err != nil {
+		return err
+	}
+	if err := gw.Close(); err != nil {
+		return err
+	}
+
+	compressed := buf.Bytes()
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(compressed)), nil
+	}
+	req.Body, _ = req.GetBody()
+	req.ContentLength = int64(len(compressed))
+	req.Header.Set("Content-Encoding", "gzip")
+	req.Header.Set("Content-Length", strconv.Itoa(len(compressed)))
+	return nil
+}
+
+// DecompressBody returns a reader of the decompressed body of resp when its
+// Content-Encoding is gzip or deflate, for transports that were configured
+// with DisableCompression or requests that set Accept-Encoding themselves.
+// Closing the reader also closes resp.Body. For other encodings resp.Body is
+// returned as is. An error is returned if the compressed stream has a
+// malformed header, while corrupt data surfaces as an error when reading.
+func DecompressBody(resp *http.Response) (io.ReadCloser, error) {
+	var (
+		dr  io.ReadCloser
+		err error
+	)
+	switch strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding"))) {
+	case "gzip", "x-gzip":
+		dr, err = gzip.NewReader(resp.Body)
+	case "deflate":
+		dr, err = newDeflateReader(resp.Body)
+	default:
+		return resp.Body, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &decompressedBody{ReadCloser: dr, body: resp.Body}, nil
+}
+
+// newDeflateReader reads the "deflate" content coding which is meant to be
+// zlib wrapped, but which some servers send as raw deflate data instead.
+func newDeflateReader(r io.Reader) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+	header, err := br.Peek(2)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	// A zlib header uses the deflate method in its low bits and
+	// is a multiple of 31 when read as a big endian number.
+	if len(header) == 2 && header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+		return zlib.NewReader(br)
+	}
+	return flate.NewReader(br), nil
+}
+
+// decompressedBody closes both the decompressor and the body that it reads.
+type decompressedBody struct {
+	io.ReadCloser
+	body io.Closer
+}
+
+func (db *decompressedBody) Close() error {
+	err := db.ReadCloser.Close()
+	if bodyErr := db.body.Close(); err == nil {
+		err = bodyErr
+	}
+	return err
+}
+
+// ClientOptions configures the http.Client returned by NewHTTPClient.
+// Fields that are left as zero take on the default noted next to them.
+type ClientOptions struct {
+	// Timeout caps the total time of a request, including reading
+	// the response body. Defaults to 30 seconds.
+	Timeout time.Duration
+	// DialTimeout caps the time to establish a TCP connection.
+	// Defaults to 10 seconds.
+	DialTimeout time.Duration
+	// TLSHandshakeTimeout caps the time of the TLS handshake.
+	// Defaults to 10 seconds.
+	TLSHandshakeTimeout time.Duration
+	// ResponseHeaderTimeout caps the time to wait for the response
+	// headers after the request was written. Defaults to 15 seconds.
+	ResponseHeaderTimeout time.Duration
+	// MaxIdleConns caps the number of idle connections kept
+	// across all hosts. Defaults to 100.
+	MaxIdleConns int
+	// IdleConnTimeout is how long an idle connection is kept
+	// before being closed. Defaults to 90 seconds.
+	IdleConnTimeout time.Duration
+}
+
+var defaultClientOptions = ClientOptions{
+	Timeout:               30 * time.Second,
+	DialTimeout:           10 * time.Second,
+	TLSHandshakeTimeout:   10 * time.Second,
+	ResponseHeaderTimeout: 15 * time.Second,
+	MaxIdleConns:          100,
+	IdleConnTimeout:       90 * time.Second,
+}
+
+// NewHTTPClient returns an http.Client with its own Transport configured
+// from opts, unlike http.DefaultClient which never times out. The Transport
+// otherwise behaves like http.DefaultTransport, honoring the proxy
+// environment variables and attempting HTTP/2.
+func NewHTTPClient(opts ClientOptions) *http.Client {
+	opts = opts.withDefaults()
+
+	dialer := &net.Dialer{
+		Timeout:   opts.DialTimeout,
+		KeepAlive: 30 * time.Second,
+	}
+	transport := &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     true,
+		TLSHandshakeTimeout:   opts.TLSHandshakeTimeout,
+		ResponseHeaderTimeout: opts.ResponseHeaderTimeout,
+		MaxIdleConns:          opts.MaxIdleConns,
+		IdleConnTimeout:       opts.IdleConnTimeout,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+	return &http.Client{
+		Timeout:   opts.Timeout,
+		Transport: transport,
+	}
+}
+
+// withDefaults returns opts with its zero fields set to their defaults.
+func (opts ClientOptions) withDefaults() ClientOptions {
+	if opts.Timeout == 0 {
+		opts.Timeout = defaultClientOptions.Timeout
+	}
+	if opts.DialTimeout == 0 {
+		opts.DialTimeout = defaultClientOptions.DialTimeout
+	}
+	if opts.TLSHandshakeTimeout == 0 {
+		opts.TLSHandshakeTimeout = defaultClientOptions.TLSHandshakeTimeout
+	}
+	if opts.ResponseHeaderTimeout == 0 {
+		opts.ResponseHeaderTimeout = defaultClientOptions.ResponseHeaderTimeout
+	}
+	if opts.MaxIdleConns == 0 {
+		opts.MaxIdleConns = defaultClientOptions.MaxIdleConns
+	}
+	if opts.IdleConnTimeout == 0 {
+		opts.IdleConnTimeout = defaultClientOptions.IdleConnTimeout
+	}
+	return opts
+}
+
+type headerInjectingTransport struct {
+	base    http.RoundTripper
+	headers http.Header
+}
+
+// HeaderInjectingTransport returns an http.RoundTripper that adds headers to
+// each request before sending it with base, e.g. to always send credentials
+// or a User-Agent. Headers that a request already sets are left as they are.
+// The requests are cloned rather than modified, as http.RoundTripper requires.
+// If base is nil, http.DefaultTransport is used.
+func HeaderInjectingTransport(base http.RoundTripper, headers http.Header) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &headerInjectingTransport{base: base, headers: headers.Clone()}
+}
+
+var _ http.RoundTripper = (*headerInjectingTransport)(nil)
+
+func (ht *headerInjectingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	outReq := req.Clone(req.Context())
+	if outReq.Header == nil {
+		outReq.Header = make(http.Header)
+	}
+	for key, values := range ht.headers {
+		if len(outReq.Header.Values(key)) == 0 {
+			outReq.Header[http.CanonicalHeaderKey(key)] = append([]string(nil), values...)
+		}
+	}
+	return ht.base.RoundTrip(outReq)
+}
+
+// CloseIdleConnections closes the idle connections of the base
+// transport, if it supports it, for http.Client.CloseIdleConnections.
+func (ht *headerInjectingTransport) CloseIdleConnections() {
+	type closeIdler interface {
+		CloseIdleConnections()
+	}
+	if ci, ok := ht.base.(closeIdler); ok {
+		ci.CloseIdleConnections()
+	}
+}
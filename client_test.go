@@ -0,0 +1,327 @@
+package otils
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGzipRequestBody(t *testing.T) {
+	payload := strings.Repeat("compress me please ", 100)
+
+	type received struct {
+		encoding string
+		body     string
+		err      error
+	}
+	recvCh := make(chan received, 1)
+	tst := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		recv := received{encoding: req.Header.Get("Content-Encoding")}
+		gr, err := gzip.NewReader(req.Body)
+		if err == nil {
+			var body []byte
+			body, err = io.ReadAll(gr)
+			recv.body = string(body)
+		}
+		recv.err = err
+		recvCh <- recv
+	}))
+	defer tst.Close()
+
+	req, err := http.NewRequest("POST", tst.URL, strings.NewReader(payload))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := GzipRequestBody(req); err != nil {
+		t.Fatal(err)
+	}
+	if req.ContentLength >= int64(len(payload)) {
+		t.Errorf("expected a compressed ContentLength, got: %d", req.ContentLength)
+	}
+	resp, err := tst.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	DrainAndClose(resp)
+
+	recv := <-recvCh
+	if recv.err != nil {
+		t.Fatalf("server failed to decompress the body: %v", recv.err)
+	}
+	if recv.encoding != "gzip" {
+		t.Errorf("unexpected Content-Encoding, want: %q, got: %q", "gzip", recv.encoding)
+	}
+	if recv.body != payload {
+		t.Errorf("decompressed body doesn't match the original")
+	}
+}
+
+func TestGzipRequestBodyNoBody(t *testing.T) {
+	req, err := http.NewRequest("GET", "https://orijtech.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := GzipRequestBody(req); err != nil {
+		t.Fatal(err)
+	}
+	if got := req.Header.Get("Content-Encoding"); got != "" {
+		t.Errorf("expected no Content-Encoding, got: %q", got)
+	}
+}
+
+func TestNewHTTPClient(t *testing.T) {
+	tests := []struct {
+		name string
+		opts ClientOptions
+		want ClientOptions
+	}{
+		{"defaults", ClientOptions{}, defaultClientOptions},
+		{
+			"custom",
+			ClientOptions{
+				Timeout:               5 * time.Second,
+				DialTimeout:           time.Second,
+				TLSHandshakeTimeout:   2 * time.Second,
+				ResponseHeaderTimeout: 3 * time.Second,
+				MaxIdleConns:          10,
+				IdleConnTimeout:       time.Minute,
+			},
+			ClientOptions{
+				Timeout:               5 * time.Second,
+				DialTimeout:           time.Second,
+				TLSHandshakeTimeout:   2 * time.Second,
+				ResponseHeaderTimeout: 3 * time.Second,
+				MaxIdleConns:          10,
+				IdleConnTimeout:       time.Minute,
+			},
+		},
+		{
+			"partial",
+			ClientOptions{Timeout: time.Second, MaxIdleConns: 4},
+			ClientOptions{
+				Timeout:               time.Second,
+				DialTimeout:           defaultClientOptions.DialTimeout,
+				TLSHandshakeTimeout:   defaultClientOptions.TLSHandshakeTimeout,
+				ResponseHeaderTimeout: defaultClientOptions.ResponseHeaderTimeout,
+				MaxIdleConns:          4,
+				IdleConnTimeout:       defaultClientOptions.IdleConnTimeout,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			client := NewHTTPClient(tc.opts)
+			transport, ok := client.Transport.(*http.Transport)
+			if !ok {
+				t.Fatalf("expected an *http.Transport, got: %T", client.Transport)
+			}
+			if transport == http.DefaultTransport {
+				t.Fatal("expected a dedicated transport, got http.DefaultTransport")
+			}
+			if transport.DialContext == nil {
+				t.Fatal("expected DialContext to be set")
+			}
+			if got := tc.opts.withDefaults().DialTimeout; got != tc.want.DialTimeout {
+				t.Errorf("unexpected dial timeout, want: %v, got: %v", tc.want.DialTimeout, got)
+			}
+			// The dial timeout is captured by DialContext so
+			// it is checked on the resolved options above.
+			got := ClientOptions{
+				Timeout:               client.Timeout,
+				DialTimeout:           tc.want.DialTimeout,
+				TLSHandshakeTimeout:   transport.TLSHandshakeTimeout,
+				ResponseHeaderTimeout: transport.ResponseHeaderTimeout,
+				MaxIdleConns:          transport.MaxIdleConns,
+				IdleConnTimeout:       transport.IdleConnTimeout,
+			}
+			if got != tc.want {
+				t.Errorf("unexpected result, want: %+v, got: %+v", tc.want, got)
+			}
+		})
+	}
+}
+
+// roundTripFunc adapts a function to an http.RoundTripper.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (fn roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return fn(req)
+}
+
+func TestHeaderInjectingTransport(t *testing.T) {
+	injected := http.Header{
+		"Authorization": {"Bearer secret"},
+		"user-agent":    {"otils"},
+		"X-Tags":        {"a", "b"},
+	}
+
+	tests := []struct {
+		name     string
+		header   http.Header
+		expected http.Header
+	}{
+		{
+			"injected",
+			http.Header{"Accept": {"text/plain"}},
+			http.Header{
+				"Accept":        {"text/plain"},
+				"Authorization": {"Bearer secret"},
+				"User-Agent":    {"otils"},
+				"X-Tags":        {"a", "b"},
+			},
+		},
+		{
+			"existing are preserved",
+			http.Header{"Authorization": {"Basic creds"}, "User-Agent": {"custom"}},
+			http.Header{
+				"Authorization": {"Basic creds"},
+				"User-Agent":    {"custom"},
+				"X-Tags":        {"a", "b"},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			var got http.Header
+			base := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				got = req.Header
+				return &http.Response{StatusCode: http.StatusNoContent, Body: http.NoBody}, nil
+			})
+			req := httptest.NewRequest("GET", "http://orijtech.com/", nil)
+			req.Header = tc.header.Clone()
+
+			res, err := HeaderInjectingTransport(base, injected).RoundTrip(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res.Body.Close()
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("unexpected headers, want: %v, got: %v", tc.expected, got)
+			}
+			if !reflect.DeepEqual(req.Header, tc.header) {
+				t.Errorf("the original request was modified: %v", req.Header)
+			}
+		})
+	}
+}
+
+func TestHeaderInjectingTransportDefaultBase(t *testing.T) {
+	var got string
+	cst := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		got = req.Header.Get("X-Api-Key")
+	}))
+	defer cst.Close()
+
+	client := &http.Client{Transport: HeaderInjectingTransport(nil, http.Header{"X-Api-Key": {"k"}})}
+	defer client.CloseIdleConnections()
+	res, err := client.Get(cst.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if got != "k" {
+		t.Errorf("unexpected X-Api-Key, want: %q, got: %q", "k", got)
+	}
+}
+
+// closeRecorder records whether the body it wraps was closed.
+type closeRecorder struct {
+	io.Reader
+	closed bool
+}
+
+func (cr *closeRecorder) Close() error {
+	cr.closed = true
+	return nil
+}
+
+func TestDecompressBody(t *testing.T) {
+	const payload = "hello, compressed world"
+	compress := func(newWriter func(io.Writer) io.WriteCloser) []byte {
+		var buf bytes.Buffer
+		w := newWriter(&buf)
+		_, _ = io.WriteString(w, payload)
+		_ = w.Close()
+		return buf.Bytes()
+	}
+	gzipped := compress(func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) })
+	zlibbed := compress(func(w io.Writer) io.WriteCloser { return zlib.NewWriter(w) })
+	deflated := compress(func(w io.Writer) io.WriteCloser {
+		fw, _ := flate.NewWriter(w, flate.DefaultCompression)
+		return fw
+	})
+
+	tests := []struct {
+		name        string
+		encoding    string
+		body        []byte
+		expected    string
+		wantOpenErr bool
+		wantReadErr bool
+	}{
+		{name: "gzip", encoding: "gzip", body: gzipped, expected: payload},
+		{name: "gzip any case", encoding: "GZIP", body: gzipped, expected: payload},
+		{name: "zlib deflate", encoding: "deflate", body: zlibbed, expected: payload},
+		{name: "raw deflate", encoding: "deflate", body: deflated, expected: payload},
+		{name: "plain", body: []byte(payload), expected: payload},
+		{name: "unknown encoding", encoding: "br", body: []byte(payload), expected: payload},
+		{name: "malformed gzip header", encoding: "gzip", body: []byte("not gzip at all"), wantOpenErr: true},
+		{name: "truncated gzip", encoding: "gzip", body: gzipped[:len(gzipped)-10], wantReadErr: true},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			body := &closeRecorder{Reader: bytes.NewReader(tc.body)}
+			resp := &http.Response{Header: make(http.Header), Body: body}
+			if tc.encoding != "" {
+				resp.Header.Set("Content-Encoding", tc.encoding)
+			}
+
+			rc, err := DecompressBody(resp)
+			if tc.wantOpenErr {
+				if err == nil {
+					t.Fatal("expected an error for a malformed stream")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := io.ReadAll(rc)
+			if tc.wantReadErr {
+				if err == nil {
+					t.Fatal("expected an error reading a corrupt stream")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tc.expected {
+				t.Errorf("unexpected body, want: %q, got: %q", tc.expected, got)
+			}
+			if err := rc.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if !body.closed {
+				t.Errorf("expected the response body to be closed")
+			}
+		})
+	}
+}
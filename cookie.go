@@ -0,0 +1,80 @@
+package otils
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// CookieOptions configures the cookies set by SetSecureCookie. Its zero
+// value is a secure, HTTP only session cookie for the whole site that
+// is only sent on same-site requests and top-level navigations.
+type CookieOptions struct {
+	// MaxAge is how long the cookie lives, rounded down to seconds.
+	// Zero makes a session cookie and a negative value deletes it.
+	MaxAge time.Duration
+	// Path defaults to "/".
+	Path   string
+	Domain string
+	// SameSite defaults to http.SameSiteLaxMode. Note that browsers
+	// reject http.SameSiteNoneMode cookies that aren't Secure.
+	SameSite http.SameSite
+
+	// DisableSecure allows the cookie to be sent over plain HTTP,
+	// e.g. for local development.
+	DisableSecure bool
+	// DisableHTTPOnly exposes the cookie to JavaScript.
+	DisableHTTPOnly bool
+}
+
+// SetSecureCookie adds a Set-Cookie header for the named cookie to rw, with
+// the attributes that opts describes. Unlike with a bare http.Cookie, the
+// Secure and HttpOnly attributes are set unless opted out of.
+func SetSecureCookie(rw http.ResponseWriter, name, value string, opts CookieOptions) {
+	cookie := &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     opts.Path,
+		Domain:   opts.Domain,
+		SameSite: opts.SameSite,
+		Secure:   !opts.DisableSecure,
+		HttpOnly: !opts.DisableHTTPOnly,
+	}
+	if cookie.Path == "" {
+		cookie.Path = "/"
+	}
+	if cookie.SameSite == 0 {
+		cookie.SameSite = http.SameSiteLaxMode
+	}
+	switch {
+	case opts.MaxAge < 0:
+		cookie.MaxAge = -1
+		cookie.Expires = time.Unix(0, 0)
+	case opts.MaxAge >= time.Second:
+		cookie.MaxAge = int(opts.MaxAge / time.Second)
+		// Expires is also set for clients that don't support Max-Age.
+		cookie.Expires = time.Now().Add(opts.MaxAge).UTC()
+	}
+	http.SetCookie(rw, cookie)
+}
+
+// DeleteCookie adds a Set-Cookie header to rw that makes browsers remove
+// the named cookie: its value is empty, it has Max-Age=0 and its Expires
+// is in the past for clients that don't support Max-Age. A cookie is only
+// removed if path and domain match those it was set with, and path
+// defaults to "/" like with SetSecureCookie. Cookies named with the
+// "__Secure-" or "__Host-" prefixes are marked Secure, as browsers
+// otherwise ignore the header.
+func DeleteCookie(rw http.ResponseWriter, name, path, domain string) {
+	if path == "" {
+		path = "/"
+	}
+	http.SetCookie(rw, &http.Cookie{
+		Name:    name,
+		Path:    path,
+		Domain:  domain,
+		MaxAge:  -1,
+		Expires: time.Unix(0, 0),
+		Secure:  strings.HasPrefix(name, "__Secure-") || strings.HasPrefix(name, "__Host-"),
+	})
+}
@@ -0,0 +1,130 @@
+package otils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSetSecureCookie(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    CookieOptions
+		want    []string
+		notWant []string
+	}{
+		{
+			name:    "defaults",
+			want:    []string{"session=abc123", "Path=/", "Secure", "HttpOnly", "SameSite=Lax"},
+			notWant: []string{"Max-Age", "Expires", "Domain"},
+		},
+		{
+			name: "custom",
+			opts: CookieOptions{
+				MaxAge:   time.Hour,
+				Path:     "/app",
+				Domain:   "orijtech.com",
+				SameSite: http.SameSiteStrictMode,
+			},
+			want: []string{"Path=/app", "Domain=orijtech.com", "Max-Age=3600", "Expires=", "Secure", "HttpOnly", "SameSite=Strict"},
+		},
+		{
+			name:    "opted out",
+			opts:    CookieOptions{DisableSecure: true, DisableHTTPOnly: true, SameSite: http.SameSiteNoneMode},
+			want:    []string{"SameSite=None"},
+			notWant: []string{"Secure", "HttpOnly"},
+		},
+		{
+			name: "delete",
+			opts: CookieOptions{MaxAge: -1},
+			want: []string{"Max-Age=0", "Expires=Thu, 01 Jan 1970 00:00:00 GMT"},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			rec := httptest.NewRecorder()
+			SetSecureCookie(rec, "session", "abc123", tc.opts)
+			header := rec.Header().Get("Set-Cookie")
+			attrs := strings.Split(header, "; ")
+			for _, want := range tc.want {
+				if !hasCookieAttr(attrs, want) {
+					t.Errorf("expected %q in Set-Cookie header: %q", want, header)
+				}
+			}
+			for _, notWant := range tc.notWant {
+				if hasCookieAttr(attrs, notWant) {
+					t.Errorf("unexpected %q in Set-Cookie header: %q", notWant, header)
+				}
+			}
+		})
+	}
+}
+
+func TestDeleteCookie(t *testing.T) {
+	tests := []struct {
+		name    string
+		cookie  string
+		path    string
+		domain  string
+		want    []string
+		notWant []string
+	}{
+		{
+			name:    "default path",
+			cookie:  "session",
+			want:    []string{"session=", "Path=/", "Max-Age=0", "Expires=Thu, 01 Jan 1970 00:00:00 GMT"},
+			notWant: []string{"Domain", "Secure"},
+		},
+		{
+			name:   "path and domain",
+			cookie: "session",
+			path:   "/app",
+			domain: "orijtech.com",
+			want:   []string{"session=", "Path=/app", "Domain=orijtech.com", "Max-Age=0", "Expires=Thu, 01 Jan 1970 00:00:00 GMT"},
+		},
+		{
+			name:   "secure prefix",
+			cookie: "__Host-session",
+			want:   []string{"__Host-session=", "Max-Age=0", "Secure"},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			rec := httptest.NewRecorder()
+			DeleteCookie(rec, tc.cookie, tc.path, tc.domain)
+			header := rec.Header().Get("Set-Cookie")
+			attrs := strings.Split(header, "; ")
+			if attrs[0] != tc.cookie+"=" {
+				t.Errorf("unexpected cookie, want: %q, got: %q", tc.cookie+"=", attrs[0])
+			}
+			for _, want := range tc.want {
+				if !hasCookieAttr(attrs, want) {
+					t.Errorf("expected %q in Set-Cookie header: %q", want, header)
+				}
+			}
+			for _, notWant := range tc.notWant {
+				if hasCookieAttr(attrs, notWant) {
+					t.Errorf("unexpected %q in Set-Cookie header: %q", notWant, header)
+				}
+			}
+		})
+	}
+}
+
+// hasCookieAttr reports whether any of attrs starts with prefix.
+func hasCookieAttr(attrs []string, prefix string) bool {
+	for _, attr := range attrs {
+		if strings.HasPrefix(attr, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -70,7 +70,3 @@ func (c *CORS) setCORSForResponseWriter(rw http.ResponseWriter) {
 		rw.Header().Add("Access-Control-Allow-Credentials", "true")
 	}
 }
-
-func unexportedField(name string) bool {
-	return len(name) > 0 && name[0] >= 'a' && name[0] <= 'z'
-}
@@ -0,0 +1,428 @@
+package otils
+
+import (
+	"encoding"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FieldError is the failure to bind the value of a query key to a field.
+type FieldError struct {
+	Key string
+	Err error
+}
+
+func (fe *FieldError) Error() string {
+	return fmt.Sprintf("%s: %v", fe.Key, fe.Err)
+}
+
+func (fe *FieldError) Unwrap() error {
+	return fe.Err
+}
+
+// FieldErrors lists all the fields that couldn't be bound, in field order.
+type FieldErrors []*FieldError
+
+func (fes FieldErrors) Error() string {
+	msgs := make([]string, len(fes))
+	for i, fe := range fes {
+		msgs[i] = fe.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// errMissingRequired is the FieldError.Err of required fields without a value.
+var errMissingRequired = errors.New("is required")
+
+// FromURLValues reverses ToURLValues for structs: it sets the fields of the
+// struct that dst points to from values, using the same keys. Nested structs
+// are read from dotted keys, embedded structs are promoted, slices of structs
+// are read from indexed keys such as "items.0.city", other slices are set from
+// repeated keys, byte slices are decoded from base64 and types that implement
+// encoding.TextUnmarshaler are decoded with it. Arrays are decoded like
+// slices, with the elements that have no value left as zero. Times are parsed
+// as RFC 3339 and booleans as by QueryBool. Maps aren't decoded, nor are the
+// options of ToURLValuesWithOptions that change keys. Fields without a value
+// in values are left untouched. Fields whose value can't be parsed don't stop the
+// decoding; they are all reported together as FieldErrors.
+func FromURLValues(values url.Values, dst interface{}) error {
+	return decodeURLValues(values, dst, false)
+}
+
+// BindQuery decodes the query of r into the struct that dst points to
+// with FromURLValues, then checks that the fields tagged as required, as
+// with ToURLValues, were given. All invalid and missing fields are reported
+// together as FieldErrors, in field order.
+func BindQuery(r *http.Request, dst interface{}) error {
+	return decodeURLValues(r.URL.Query(), dst, true)
+}
+
+func decodeURLValues(values url.Values, dst interface{}, checkRequired bool) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("expecting a non-nil pointer to a struct, got %T", dst)
+	}
+	dec := &urlDecoder{values: values, checkRequired: checkRequired}
+	dec.decodeStruct("", v.Elem())
+	if len(dec.errs) > 0 {
+		return dec.errs
+	}
+	return nil
+}
+
+type urlDecoder struct {
+	values url.Values
+	// checkRequired reports the required fields that are left empty.
+	checkRequired bool
+
+	errs FieldErrors
+}
+
+func (dec *urlDecoder) fail(key string, err error) {
+	dec.errs = append(dec.errs, &FieldError{Key: key, Err: err})
+}
+
+func (dec *urlDecoder) decodeStruct(prefix string, v reflect.Value) {
+	typ := v.Type()
+	for i := 0; i < v.NumField(); i++ {
+		fieldVal := v.Field(i)
+		fieldTyp := typ.Field(i)
+		if unexportedField(fieldTyp) {
+			// Like encoding/json, pointers to unexported types are
+			// skipped since they can't be allocated through reflection.
+			if fieldVal.Kind() == reflect.Struct && promotedUnexported(fieldTyp, fieldVal) {
+				dec.decodeStruct(prefix, fieldVal)
+			}
+			continue
+		}
+
+		tag, tagOpts, ignore := fieldTag(fieldTyp)
+		if ignore {
+			continue
+		}
+		key := tag
+		if prefix != "" {
+			key = prefix + "." + tag
+		}
+		if fieldTyp.Anonymous && fieldTyp.Type.Kind() != reflect.Interface && flattenEmbedded(fieldTyp, fieldVal) {
+			key = prefix
+			// The keys of the promoted fields are those of the
+			// siblings, so only those of its own fields count.
+			if fieldVal.Kind() == reflect.Ptr && !dec.hasFieldValues(prefix, fieldTyp.Type.Elem(), nil) {
+				continue
+			}
+		}
+
+		failed := len(dec.errs)
+		if tagOpts.has("json") {
+			if raw := dec.values[key]; len(raw) > 0 {
+				if err := json.Unmarshal([]byte(raw[0]), fieldVal.Addr().Interface()); err != nil {
+					dec.fail(key, err)
+				}
+			}
+		} else {
+			dec.decode(key, fieldVal)
+		}
+
+		if dec.checkRequired && len(dec.errs) == failed &&
+			isRequiredField(fieldTyp, tagOpts) && isEmptyFieldValue(fieldVal) {
+			dec.fail(key, errMissingRequired)
+		}
+	}
+}
+
+// decode sets v from the values under key, allocating pointers
+// only if there is a value for them to point to.
+func (dec *urlDecoder) decode(key string, v reflect.Value) {
+	if v.Kind() == reflect.Ptr {
+		if !dec.hasValuesFor(key, v.Type().Elem()) {
+			return
+		}
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		dec.decode(key, v.Elem())
+		return
+	}
+
+	if isTextUnmarshaler(v) || v.Type() == timeType {
+		if raw := dec.values[key]; len(raw) > 0 {
+			if err := setScalar(v, raw[0]); err != nil {
+				dec.fail(key, err)
+			}
+		}
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		dec.decodeStruct(key, v)
+
+	case reflect.Slice:
+		if isIndexedElem(v.Type().Elem()) {
+			dec.decodeIndexed(key, v)
+			return
+		}
+		raw, ok := dec.values[key]
+		if !ok {
+			return
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			if len(raw) > 0 {
+				b, err := base64.StdEncoding.DecodeString(raw[0])
+				if err != nil {
+					dec.fail(key, err)
+					return
+				}
+				v.SetBytes(b)
+			}
+			return
+		}
+		slice := reflect.MakeSlice(v.Type(), len(raw), len(raw))
+		for i, s := range raw {
+			if err := setScalar(slice.Index(i), s); err != nil {
+				dec.fail(key, err)
+				return
+			}
+		}
+		v.Set(slice)
+
+	case reflect.Array:
+		dec.decodeArray(key, v)
+
+	default:
+		if raw := dec.values[key]; len(raw) > 0 {
+			if err := setScalar(v, raw[0]); err != nil {
+				dec.fail(key, err)
+			}
+		}
+	}
+}
+
+// maxDecodedIndex bounds the indices of decoded struct slices, so that a
+// query such as "items.999999999.id=1" can't make them allocate lots of memory.
+const maxDecodedIndex = 1000
+
+// isIndexedElem reports whether slices of typ are emitted under indexed
+// keys, such as "items.0.city", which is the case for struct elements.
+func isIndexedElem(typ reflect.Type) bool {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	return typ.Kind() == reflect.Struct && typ != timeType && !reflect.PtrTo(typ).Implements(textUnmarshalerType)
+}
+
+// decodeIndexed sets the slice v from the fields under "key.<index>.",
+// leaving the elements of missing indices as zero values.
+func (dec *urlDecoder) decodeIndexed(key string, v reflect.Value) {
+	n := 0
+	prefix := key + "."
+	for k := range dec.values {
+		if !strings.HasPrefix(k, prefix) {
+			continue
+		}
+		rest := k[len(prefix):]
+		end := strings.IndexByte(rest, '.')
+		if end < 0 {
+			continue
+		}
+		i, err := strconv.Atoi(rest[:end])
+		if err != nil || i < 0 {
+			continue
+		}
+		if i >= maxDecodedIndex {
+			dec.fail(key, fmt.Errorf("indices must be below %d", maxDecodedIndex))
+			return
+		}
+		if i >= n {
+			n = i + 1
+		}
+	}
+	if n == 0 {
+		return
+	}
+	slice := reflect.MakeSlice(v.Type(), n, n)
+	for i := 0; i < n; i++ {
+		dec.decode(prefix+strconv.Itoa(i), slice.Index(i))
+	}
+	v.Set(slice)
+}
+
+// decodeArray sets the array v like a slice of its length: from indexed keys
+// for struct elements, from base64 for bytes and from repeated keys otherwise.
+// Elements without a value are set to zero and having more values than the
+// array has elements is an error.
+func (dec *urlDecoder) decodeArray(key string, v reflect.Value) {
+	if isIndexedElem(v.Type().Elem()) {
+		for i := 0; i < v.Len(); i++ {
+			dec.decode(key+"."+strconv.Itoa(i), v.Index(i))
+		}
+		return
+	}
+	raw, ok := dec.values[key]
+	if !ok {
+		return
+	}
+	arr := reflect.New(v.Type()).Elem()
+	if v.Type().Elem().Kind() == reflect.Uint8 {
+		if len(raw) == 0 {
+			return
+		}
+		b, err := base64.StdEncoding.DecodeString(raw[0])
+		if err != nil {
+			dec.fail(key, err)
+			return
+		}
+		if len(b) != v.Len() {
+			dec.fail(key, fmt.Errorf("expecting %d bytes, got %d", v.Len(), len(b)))
+			return
+		}
+		reflect.Copy(arr, reflect.ValueOf(b))
+		v.Set(arr)
+		return
+	}
+	if len(raw) > v.Len() {
+		dec.fail(key, fmt.Errorf("expecting at most %d values, got %d", v.Len(), len(raw)))
+		return
+	}
+	for i, s := range raw {
+		if err := setScalar(arr.Index(i), s); err != nil {
+			dec.fail(key, err)
+			return
+		}
+	}
+	v.Set(arr)
+}
+
+// hasValuesFor reports whether there are values to decode
+// into a value of typ, under key or nested below it.
+func (dec *urlDecoder) hasValuesFor(key string, typ reflect.Type) bool {
+	if _, ok := dec.values[key]; ok {
+		return true
+	}
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	indexed := (typ.Kind() == reflect.Slice || typ.Kind() == reflect.Array) && isIndexedElem(typ.Elem())
+	if (typ.Kind() != reflect.Struct || typ == timeType) && !indexed {
+		return false
+	}
+	prefix := key + "."
+	if key == "" {
+		prefix = ""
+	}
+	for k := range dec.values {
+		if strings.HasPrefix(k, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasFieldValues reports whether there are values for any of the fields of
+// the struct type typ, when they are decoded under prefix. seen breaks the
+// cycles of recursively embedded types.
+func (dec *urlDecoder) hasFieldValues(prefix string, typ reflect.Type, seen map[reflect.Type]bool) bool {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct || seen[typ] {
+		return false
+	}
+	if seen == nil {
+		seen = make(map[reflect.Type]bool)
+	}
+	seen[typ] = true
+
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		embedded := field.Anonymous && field.Type.Kind() != reflect.Interface && flattenEmbedded(field, reflect.Value{})
+		if unexportedField(field) && !(embedded && field.Type.Kind() == reflect.Struct) {
+			continue
+		}
+		tag, _, ignore := fieldTag(field)
+		if ignore {
+			continue
+		}
+		if embedded {
+			if dec.hasFieldValues(prefix, field.Type, seen) {
+				return true
+			}
+			continue
+		}
+		key := tag
+		if prefix != "" {
+			key = prefix + "." + tag
+		}
+		if dec.hasValuesFor(key, field.Type) {
+			return true
+		}
+	}
+	return false
+}
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+func isTextUnmarshaler(v reflect.Value) bool {
+	return v.CanAddr() && reflect.PtrTo(v.Type()).Implements(textUnmarshalerType)
+}
+
+// setScalar parses s into v, which must be addressable.
+func setScalar(v reflect.Value, s string) error {
+	if isTextUnmarshaler(v) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+	if v.Type() == timeType {
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := parseBoolLenient(s)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", s)
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", s)
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid unsigned integer %q", s)
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid number %q", s)
+		}
+		v.SetFloat(f)
+	case reflect.Ptr:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return setScalar(v.Elem(), s)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
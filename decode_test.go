@@ -0,0 +1,232 @@
+package otils
+
+import (
+	"errors"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type searchQuery struct {
+	Query    string    `json:"q" url:"q,required"`
+	Page     int       `json:"page"`
+	Exact    bool      `json:"exact"`
+	Tags     []string  `json:"tags"`
+	Since    time.Time `json:"since"`
+	MinScore *float64  `json:"min_score"`
+	Cursor   []byte    `json:"cursor"`
+	Filter   struct {
+		Region regionCode `json:"region" validate:"required"`
+	} `json:"filter"`
+	Ignored string `json:"-"`
+}
+
+// regionCode implements encoding.TextUnmarshaler on its pointer receiver.
+type regionCode string
+
+func (r *regionCode) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		return errors.New("empty region")
+	}
+	*r = regionCode("region-" + string(text))
+	return nil
+}
+
+func TestFromURLValues(t *testing.T) {
+	values := url.Values{
+		"q":             {"gophers"},
+		"page":          {"2"},
+		"exact":         {"yes"},
+		"tags":          {"a", "b"},
+		"since":         {"2021-03-05T16:26:07Z"},
+		"min_score":     {"0.5"},
+		"cursor":        {"b3JpanRlY2g="},
+		"filter.region": {"eu"},
+		"Ignored":       {"x"},
+	}
+	var got searchQuery
+	if err := FromURLValues(values, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	minScore := 0.5
+	var want searchQuery
+	want.Query, want.Page, want.Exact = "gophers", 2, true
+	want.Tags = []string{"a", "b"}
+	want.Since = time.Date(2021, 3, 5, 16, 26, 7, 0, time.UTC)
+	want.MinScore = &minScore
+	want.Cursor = []byte("orijtech")
+	want.Filter.Region = "region-eu"
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected result, want: %+v, got: %+v", want, got)
+	}
+
+	if err := FromURLValues(values, got); err == nil {
+		t.Errorf("expected an error for a non-pointer destination")
+	}
+}
+
+func TestBindQuery(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		expected []string
+	}{
+		{"valid", "q=gophers&page=3&filter.region=eu", nil},
+		{"missing required field", "page=3&filter.region=eu", []string{"q"}},
+		{"type mismatch", "q=gophers&page=three&filter.region=eu", []string{"page"}},
+		{
+			"all errors are reported",
+			"page=three&exact=maybe&min_score=high&filter.region=",
+			[]string{"q", "page", "exact", "min_score", "filter.region"},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			req := httptest.NewRequest("GET", "/search?"+tc.query, nil)
+			var dst searchQuery
+			err := BindQuery(req, &dst)
+			if tc.expected == nil {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			var fieldErrs FieldErrors
+			if !errors.As(err, &fieldErrs) {
+				t.Fatalf("expected FieldErrors, got: %v", err)
+			}
+			var keys []string
+			for _, fe := range fieldErrs {
+				keys = append(keys, fe.Key)
+			}
+			if !reflect.DeepEqual(keys, tc.expected) {
+				t.Errorf("unexpected fields, want: %q, got: %q (%v)", tc.expected, keys, err)
+			}
+		})
+	}
+}
+
+func TestFromURLValuesUnexportedEmbedded(t *testing.T) {
+	type page struct {
+		Page int `json:"page"`
+	}
+	type listQuery struct {
+		page
+		Query string `json:"q"`
+	}
+	var got listQuery
+	if err := FromURLValues(url.Values{"q": {"gophers"}, "page": {"2"}}, &got); err != nil {
+		t.Fatal(err)
+	}
+	if want := (listQuery{page: page{Page: 2}, Query: "gophers"}); got != want {
+		t.Errorf("unexpected result, want: %+v, got: %+v", want, got)
+	}
+}
+
+func TestFromURLValuesStructSlices(t *testing.T) {
+	type address struct {
+		City string `json:"city"`
+	}
+	type order struct {
+		Items []address  `json:"items"`
+		Ptrs  []*address `json:"ptrs"`
+	}
+	want := order{
+		Items: []address{{City: "Lagos"}, {}, {City: "Oslo"}},
+		Ptrs:  []*address{nil, {City: "Lima"}},
+	}
+	values, err := ToURLValues(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got order
+	if err := FromURLValues(values, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected result, want: %+v, got: %+v", want, got)
+	}
+
+	err = FromURLValues(url.Values{"items.5000.city": {"x"}}, &got)
+	var fes FieldErrors
+	if !errors.As(err, &fes) || len(fes) != 1 || fes[0].Key != "items" {
+		t.Errorf("expected a FieldError for items, got: %v", err)
+	}
+}
+
+func TestFromURLValuesEmbeddedPointer(t *testing.T) {
+	type Base struct {
+		ID   int      `json:"id"`
+		Tags []string `json:"tags"`
+	}
+	type item struct {
+		*Base
+		Name string `json:"name"`
+	}
+	tests := []struct {
+		name     string
+		values   url.Values
+		expected item
+	}{
+		{"only sibling keys", url.Values{"name": {"x"}}, item{Name: "x"}},
+		{"own keys", url.Values{"name": {"x"}, "id": {"7"}}, item{Base: &Base{ID: 7}, Name: "x"}},
+		{"own repeated keys", url.Values{"tags": {"a"}}, item{Base: &Base{Tags: []string{"a"}}}},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			var got item
+			if err := FromURLValues(tc.values, &got); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("unexpected result, want: %+v, got: %+v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestFromURLValuesArrays(t *testing.T) {
+	type point struct {
+		X int `json:"x"`
+	}
+	type shape struct {
+		Coords [3]int    `json:"coords"`
+		Zero   [2]int    `json:"zero"`
+		Digest [4]byte   `json:"digest"`
+		Points [2]point  `json:"points"`
+		Names  [2]string `json:"names"`
+	}
+	want := shape{
+		Coords: [3]int{1, 0, 3},
+		Digest: [4]byte{0xde, 0xad, 0xbe, 0xef},
+		Points: [2]point{{X: 1}, {X: 2}},
+		Names:  [2]string{"a", "b"},
+	}
+	values, err := ToURLValues(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got shape
+	if err := FromURLValues(values, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("unexpected result, want: %+v, got: %+v", want, got)
+	}
+
+	err = FromURLValues(url.Values{"names": {"a", "b", "c"}}, &got)
+	var fes FieldErrors
+	if !errors.As(err, &fes) || len(fes) != 1 || fes[0].Key != "names" {
+		t.Errorf("expected a FieldError for names, got: %v", err)
+	}
+}
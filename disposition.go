@@ -0,0 +1,128 @@
+package otils
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// FilenameFromContentDisposition returns the filename suggested by a
+// Content-Disposition header value. The RFC 5987 "filename*" parameter,
+// whose percent-encoding and charset are decoded, is preferred over the
+// plain "filename" parameter. It returns "" if neither parameter is present.
+func FilenameFromContentDisposition(header string) string {
+	if _, params, err := mime.ParseMediaType(header); err == nil {
+		if filename := params["filename"]; filename != "" {
+			return filename
+		}
+	}
+
+	// mime.ParseMediaType is strict about the syntax, rejecting for example
+	// unquoted filenames with spaces, and only decodes the UTF-8 and US-ASCII
+	// charsets, hence the fallback to a more lenient scan of the parameters.
+	var plain, extended string
+	for _, param := range strings.Split(header, ";") {
+		splits := strings.SplitN(param, "=", 2)
+		if len(splits) != 2 {
+			continue
+		}
+		key, value := strings.ToLower(strings.TrimSpace(splits[0])), strings.TrimSpace(splits[1])
+		switch key {
+		case "filename":
+			if unquoted, err := strconv.Unquote(value); err == nil {
+				value = unquoted
+			}
+			plain = value
+		case "filename*":
+			extended = decodeExtValue(value)
+		}
+	}
+	return FirstNonEmptyString(extended, plain)
+}
+
+// decodeExtValue decodes an RFC 5987 ext-value of the form
+// charset'language'percent-encoded-value, returning "" if
+// it is malformed or uses an unsupported charset.
+func decodeExtValue(s string) string {
+	splits := strings.SplitN(s, "'", 3)
+	if len(splits) != 3 {
+		return ""
+	}
+	value, err := url.PathUnescape(splits[2])
+	if err != nil {
+		return ""
+	}
+	switch strings.ToLower(splits[0]) {
+	case "utf-8", "us-ascii":
+		return value
+	case "iso-8859-1":
+		// Each byte of ISO-8859-1 maps directly to the same Unicode code point.
+		runes := make([]rune, len(value))
+		for i := 0; i < len(value); i++ {
+			runes[i] = rune(value[i])
+		}
+		return string(runes)
+	default:
+		return ""
+	}
+}
+
+// ServeDownload responds to r with content as a file attachment named
+// filename, which FilenameFromContentDisposition can read back. Non-ASCII
+// filenames are sent in the RFC 5987 "filename*" parameter along with an
+// ASCII approximation in "filename" for older clients. If contentType is
+// empty it is detected from the extension of filename or from content.
+// Range and conditional requests are handled by http.ServeContent.
+func ServeDownload(rw http.ResponseWriter, r *http.Request, filename string, contentType string, content io.ReadSeeker) {
+	rw.Header().Set("Content-Disposition", contentDisposition(filename))
+	if contentType != "" {
+		rw.Header().Set("Content-Type", contentType)
+	}
+	http.ServeContent(rw, r, filename, time.Time{}, content)
+}
+
+// contentDisposition returns an attachment Content-Disposition for filename.
+func contentDisposition(filename string) string {
+	if isASCII(filename) {
+		if header := mime.FormatMediaType("attachment", map[string]string{"filename": filename}); header != "" {
+			return header
+		}
+	}
+
+	fallback := make([]byte, 0, len(filename))
+	for _, r := range filename {
+		if r < utf8.RuneSelf && r >= ' ' && r != 0x7f {
+			fallback = append(fallback, byte(r))
+		} else {
+			fallback = append(fallback, '_')
+		}
+	}
+	header := mime.FormatMediaType("attachment", map[string]string{"filename": string(fallback)})
+	if header == "" {
+		header = "attachment"
+	}
+	return header + "; filename*=UTF-8''" + encodeExtValue(filename)
+}
+
+// encodeExtValue percent-encodes s for use as the value of an
+// RFC 5987 ext-value, leaving only its attr-char bytes as they are.
+func encodeExtValue(s string) string {
+	const attrChars = "!#$&+-.^_`|~"
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9', strings.IndexByte(attrChars, c) >= 0:
+			sb.WriteByte(c)
+		default:
+			fmt.Fprintf(&sb, "%%%02X", c)
+		}
+	}
+	return sb.String()
+}
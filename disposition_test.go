@@ -0,0 +1,113 @@
+package otils
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestFilenameFromContentDisposition(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   string
+		expected string
+	}{
+		{"plain", `attachment; filename=report.pdf`, "report.pdf"},
+		{"quoted with spaces", `attachment; filename="annual report.pdf"`, "annual report.pdf"},
+		{"unquoted with spaces", `attachment; filename=annual report.pdf`, "annual report.pdf"},
+		{"utf-8 extended", `attachment; filename*=UTF-8''%e2%82%ac%20rates.txt`, "€ rates.txt"},
+		{
+			"extended preferred over plain",
+			`attachment; filename="rates.txt"; filename*=UTF-8''%e2%82%ac%20rates.txt`,
+			"€ rates.txt",
+		},
+		{"iso-8859-1 extended", `attachment; filename*=iso-8859-1'en'%E9t%E9.txt`, "été.txt"},
+		{"absent", `attachment`, ""},
+		{"empty", ``, ""},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if got := FilenameFromContentDisposition(tc.header); got != tc.expected {
+				t.Errorf("unexpected result, want: %q, got: %q", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestServeDownload(t *testing.T) {
+	const content = "0123456789"
+	tests := []struct {
+		name            string
+		filename        string
+		contentType     string
+		rangeHeader     string
+		wantStatus      int
+		wantDisposition string
+		wantType        string
+		wantBody        string
+	}{
+		{
+			name:            "ascii filename",
+			filename:        "annual report.csv",
+			contentType:     "text/csv",
+			wantStatus:      http.StatusOK,
+			wantDisposition: `attachment; filename="annual report.csv"`,
+			wantType:        "text/csv",
+			wantBody:        content,
+		},
+		{
+			name:            "utf-8 filename",
+			filename:        "€ rates.txt",
+			wantStatus:      http.StatusOK,
+			wantDisposition: `attachment; filename="_ rates.txt"; filename*=UTF-8''%E2%82%AC%20rates.txt`,
+			wantType:        "text/plain; charset=utf-8",
+			wantBody:        content,
+		},
+		{
+			name:            "range request",
+			filename:        "digits.bin",
+			contentType:     "application/octet-stream",
+			rangeHeader:     "bytes=2-5",
+			wantStatus:      http.StatusPartialContent,
+			wantDisposition: `attachment; filename=digits.bin`,
+			wantType:        "application/octet-stream",
+			wantBody:        "2345",
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			req := httptest.NewRequest("GET", "/download", nil)
+			if tc.rangeHeader != "" {
+				req.Header.Set("Range", tc.rangeHeader)
+			}
+			rec := httptest.NewRecorder()
+			ServeDownload(rec, req, tc.filename, tc.contentType, strings.NewReader(content))
+
+			res := rec.Result()
+			body, _ := io.ReadAll(res.Body)
+			if res.StatusCode != tc.wantStatus {
+				t.Errorf("unexpected status, want: %d, got: %d", tc.wantStatus, res.StatusCode)
+			}
+			if got := res.Header.Get("Content-Disposition"); got != tc.wantDisposition {
+				t.Errorf("unexpected Content-Disposition, want: %q, got: %q", tc.wantDisposition, got)
+			}
+			if got := FilenameFromContentDisposition(res.Header.Get("Content-Disposition")); got != tc.filename {
+				t.Errorf("unexpected round tripped filename, want: %q, got: %q", tc.filename, got)
+			}
+			if got := res.Header.Get("Content-Type"); got != tc.wantType {
+				t.Errorf("unexpected Content-Type, want: %q, got: %q", tc.wantType, got)
+			}
+			if got := string(body); got != tc.wantBody {
+				t.Errorf("unexpected body, want: %q, got: %q", tc.wantBody, got)
+			}
+		})
+	}
+}
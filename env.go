@@ -17,3 +17,21 @@ func EnvOrAlternates(envVar string, alternates ...string) string {
 	}
 	return ""
 }
+
+// EnvOrDefault returns the trimmed value of the environment
+// variable envKey if it is non-blank, otherwise it returns def.
+func EnvOrDefault(envKey, def string) string {
+	return EnvOrDefaults([]string{envKey}, def)
+}
+
+// EnvOrDefaults tries each of envKeys in order and returns the
+// trimmed value of the first environment variable that is non-blank.
+// If none of them are set, it returns def.
+func EnvOrDefaults(envKeys []string, def string) string {
+	for _, envKey := range envKeys {
+		if value := FirstNonEmptyString(os.Getenv(envKey)); value != "" {
+			return strings.TrimSpace(value)
+		}
+	}
+	return def
+}
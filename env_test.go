@@ -0,0 +1,58 @@
+package otils
+
+import "testing"
+
+func TestEnvOrDefault(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      map[string]string
+		expected string
+	}{
+		{"set", map[string]string{"OTILS_TEST_KEY": "value"}, "value"},
+		{"set with spaces", map[string]string{"OTILS_TEST_KEY": "  value \t"}, "value"},
+		{"unset", nil, "default"},
+		{"whitespace only", map[string]string{"OTILS_TEST_KEY": " \t "}, "default"},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("OTILS_TEST_KEY", "")
+			for key, value := range tc.env {
+				t.Setenv(key, value)
+			}
+			if got := EnvOrDefault("OTILS_TEST_KEY", "default"); got != tc.expected {
+				t.Errorf("unexpected result, want: %q, got: %q", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestEnvOrDefaults(t *testing.T) {
+	keys := []string{"OTILS_TEST_PRIMARY", "OTILS_TEST_SECONDARY"}
+	tests := []struct {
+		name     string
+		env      map[string]string
+		expected string
+	}{
+		{"first set", map[string]string{"OTILS_TEST_PRIMARY": "a", "OTILS_TEST_SECONDARY": "b"}, "a"},
+		{"first whitespace only", map[string]string{"OTILS_TEST_PRIMARY": "  ", "OTILS_TEST_SECONDARY": " b "}, "b"},
+		{"only second set", map[string]string{"OTILS_TEST_SECONDARY": "b"}, "b"},
+		{"none set", nil, "default"},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			for _, key := range keys {
+				t.Setenv(key, "")
+			}
+			for key, value := range tc.env {
+				t.Setenv(key, value)
+			}
+			if got := EnvOrDefaults(keys, "default"); got != tc.expected {
+				t.Errorf("unexpected result, want: %q, got: %q", tc.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package otils
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"net/http"
+	"net/textproto"
+	"sort"
+	"strings"
+)
+
+// SetHeaders sets each of headers on rw, replacing
+// any values that were already set. Empty keys are skipped.
+func SetHeaders(rw http.ResponseWriter, headers map[string]string) {
+	for key, value := range headers {
+		if key != "" {
+			rw.Header().Set(key, value)
+		}
+	}
+}
+
+// AddHeaders adds each of headers to rw, appending to
+// any values that were already set. Empty keys are skipped.
+func AddHeaders(rw http.ResponseWriter, headers map[string]string) {
+	for key, value := range headers {
+		if key != "" {
+			rw.Header().Add(key, value)
+		}
+	}
+}
+
+// CanonicalizeHeaders returns a copy of h with every key in its canonical
+// form, as textproto.CanonicalMIMEHeaderKey returns it, merging the values of
+// keys that only differ in case such as "content-type" and "Content-Type".
+// Since maps have no order, the values of a key that was already canonical
+// come first followed by those of the other variants in their sorted order.
+func CanonicalizeHeaders(h http.Header) http.Header {
+	keys := make([]string, 0, len(h))
+	for key := range h {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		iCanonical := keys[i] == textproto.CanonicalMIMEHeaderKey(keys[i])
+		jCanonical := keys[j] == textproto.CanonicalMIMEHeaderKey(keys[j])
+		if iCanonical != jCanonical {
+			return iCanonical
+		}
+		return keys[i] < keys[j]
+	})
+
+	canonical := make(http.Header, len(h))
+	for _, key := range keys {
+		ck := textproto.CanonicalMIMEHeaderKey(key)
+		canonical[ck] = append(canonical[ck], h[key]...)
+	}
+	return canonical
+}
+
+// MergeHeaders returns a new header holding the headers of both base and
+// override, with the values of override replacing those of base for the keys
+// that both have. Keys are matched and returned in their canonical form as by
+// CanonicalizeHeaders, and neither input is modified.
+func MergeHeaders(base, override http.Header) http.Header {
+	merged := CanonicalizeHeaders(base)
+	for key, values := range CanonicalizeHeaders(override) {
+		merged[key] = values
+	}
+	return merged
+}
+
+// CSPNonce generates a random nonce, sets the Content-Security-Policy header
+// of rw to policyTemplate with each "{nonce}" placeholder replaced by it, and
+// returns the nonce so that it can be set on the inline scripts and styles of
+// the response, e.g. with the template
+//
+//	script-src 'nonce-{nonce}' 'strict-dynamic'; object-src 'none'
+//
+// A new nonce must be generated for every response. CSPNonce panics if the
+// system's secure random number generator fails.
+func CSPNonce(rw http.ResponseWriter, policyTemplate string) (nonce string) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic("otils: generating CSP nonce: " + err.Error())
+	}
+	nonce = base64.StdEncoding.EncodeToString(b)
+	rw.Header().Set("Content-Security-Policy", strings.ReplaceAll(policyTemplate, "{nonce}", nonce))
+	return nonce
+}
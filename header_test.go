@@ -0,0 +1,139 @@
+package otils
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSetHeaders(t *testing.T) {
+	rec := httptest.NewRecorder()
+	rec.Header().Set("Content-Type", "text/plain")
+	rec.Header().Set("X-Kept", "yes")
+
+	SetHeaders(rec, map[string]string{
+		"content-type": "application/json",
+		"X-Frame":      "DENY",
+		"":             "skipped",
+	})
+
+	want := http.Header{
+		"Content-Type": {"application/json"},
+		"X-Frame":      {"DENY"},
+		"X-Kept":       {"yes"},
+	}
+	if got := rec.Header(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Mismatched headers\nGot:  %s\nWant: %s", asJSON(got), asJSON(want))
+	}
+}
+
+func TestAddHeaders(t *testing.T) {
+	rec := httptest.NewRecorder()
+	rec.Header().Set("Vary", "Accept")
+
+	AddHeaders(rec, map[string]string{"Vary": "Origin", "X-Frame": "DENY", "": "skipped"})
+	AddHeaders(rec, map[string]string{"vary": "Accept-Encoding"})
+
+	want := http.Header{
+		"Vary":    {"Accept", "Origin", "Accept-Encoding"},
+		"X-Frame": {"DENY"},
+	}
+	if got := rec.Header(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Mismatched headers\nGot:  %s\nWant: %s", asJSON(got), asJSON(want))
+	}
+}
+
+func TestCanonicalizeHeaders(t *testing.T) {
+	h := http.Header{
+		"content-type": {"text/plain"},
+		"Content-Type": {"application/json"},
+		"CONTENT-TYPE": {"text/html"},
+		"x-request-id": {"abc"},
+		"Accept":       {"*/*", "text/html"},
+	}
+	want := http.Header{
+		"Content-Type": {"application/json", "text/html", "text/plain"},
+		"X-Request-Id": {"abc"},
+		"Accept":       {"*/*", "text/html"},
+	}
+
+	got := CanonicalizeHeaders(h)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected result, want: %v, got: %v", want, got)
+	}
+	if len(h) != 5 {
+		t.Errorf("expected the input to be left unmodified, got: %v", h)
+	}
+}
+
+func TestMergeHeaders(t *testing.T) {
+	tests := []struct {
+		name     string
+		base     http.Header
+		override http.Header
+		expected http.Header
+	}{
+		{
+			"overlapping keys",
+			http.Header{"Accept": {"*/*"}, "User-Agent": {"otils"}},
+			http.Header{"accept": {"application/json"}, "User-Agent": {"custom", "other"}},
+			http.Header{"Accept": {"application/json"}, "User-Agent": {"custom", "other"}},
+		},
+		{
+			"disjoint keys",
+			http.Header{"x-request-id": {"abc"}},
+			http.Header{"Authorization": {"Bearer t"}},
+			http.Header{"X-Request-Id": {"abc"}, "Authorization": {"Bearer t"}},
+		},
+		{"nil override", http.Header{"Accept": {"*/*"}}, nil, http.Header{"Accept": {"*/*"}}},
+		{"nil base", nil, http.Header{"Accept": {"*/*"}}, http.Header{"Accept": {"*/*"}}},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			base, override := tc.base.Clone(), tc.override.Clone()
+			got := MergeHeaders(base, override)
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("unexpected result, want: %v, got: %v", tc.expected, got)
+			}
+			if !reflect.DeepEqual(base, tc.base) || !reflect.DeepEqual(override, tc.override) {
+				t.Errorf("expected the inputs to be left unmodified")
+			}
+
+			// The result must not share its values with the inputs.
+			for _, values := range got {
+				values[0] = "changed"
+			}
+			if !reflect.DeepEqual(base, tc.base) || !reflect.DeepEqual(override, tc.override) {
+				t.Errorf("the result aliases the values of the inputs")
+			}
+		})
+	}
+}
+
+func TestCSPNonce(t *testing.T) {
+	const template = "default-src 'self'; script-src 'nonce-{nonce}'; style-src 'nonce-{nonce}'"
+	rec := httptest.NewRecorder()
+	nonce := CSPNonce(rec, template)
+
+	raw, err := base64.StdEncoding.DecodeString(nonce)
+	if err != nil || len(raw) != 16 {
+		t.Fatalf("expected a base64 encoded 16 byte nonce, got: %q", nonce)
+	}
+	want := "default-src 'self'; script-src 'nonce-" + nonce + "'; style-src 'nonce-" + nonce + "'"
+	if got := rec.Header().Get("Content-Security-Policy"); got != want {
+		t.Errorf("unexpected policy, want: %q, got: %q", want, got)
+	}
+	if got := rec.Header().Get("Content-Security-Policy"); strings.Contains(got, "{nonce}") {
+		t.Errorf("placeholder left in policy: %q", got)
+	}
+
+	if other := CSPNonce(httptest.NewRecorder(), template); other == nonce {
+		t.Errorf("expected a new nonce for every call, got %q twice", nonce)
+	}
+}
@@ -1,8 +1,14 @@
 package otils
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"io"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 )
 
 // RedirectAllTrafficTo creates a handler that can be attached
@@ -56,3 +62,153 @@ func MakeCodedError(msg string, code int) *CodedError {
 		code: code,
 	}
 }
+
+// ResponseIsEmpty reports whether resp has no meaningful body, that is
+// when resp is nil, has a 204 No Content or 304 Not Modified status, or
+// has a ContentLength of 0. It does not read from the body.
+func ResponseIsEmpty(resp *http.Response) bool {
+	if resp == nil {
+		return true
+	}
+	switch resp.StatusCode {
+	case http.StatusNoContent, http.StatusNotModified:
+		return true
+	}
+	return resp.ContentLength == 0
+}
+
+// PaginationLinkHeader builds the value of an RFC 5988 Link header for
+// page out of totalPages, with pages numbered from 1. It always includes
+// the "first" and "last" relations, and "prev" and "next" unless page is
+// at the respective boundary. Each link is baseURL with its "page" and
+// "per_page" query parameters set. A page outside of [1, totalPages] is
+// clamped to the nearest boundary. It returns "" if totalPages is less
+// than 1 or if baseURL can't be parsed.
+func PaginationLinkHeader(baseURL string, page, perPage, totalPages int) string {
+	if totalPages < 1 {
+		return ""
+	}
+	if page < 1 {
+		page = 1
+	} else if page > totalPages {
+		page = totalPages
+	}
+
+	type relLink struct {
+		rel  string
+		page int
+	}
+	links := []relLink{{"first", 1}}
+	if page > 1 {
+		links = append(links, relLink{"prev", page - 1})
+	}
+	if page < totalPages {
+		links = append(links, relLink{"next", page + 1})
+	}
+	links = append(links, relLink{"last", totalPages})
+
+	entries := make([]string, 0, len(links))
+	for _, link := range links {
+		linkURL, err := WithQuery(baseURL, url.Values{
+			"page":     {strconv.Itoa(link.page)},
+			"per_page": {strconv.Itoa(perPage)},
+		})
+		if err != nil {
+			return ""
+		}
+		entries = append(entries, fmt.Sprintf("<%s>; rel=%q", linkURL, link.rel))
+	}
+	return strings.Join(entries, ", ")
+}
+
+// maxDrainBytes caps how much of a response body DrainAndClose will
+// read. Bodies larger than it are cheaper to discard along with their
+// connection than to read in full.
+const maxDrainBytes = 64 << 10
+
+// DrainAndClose reads what remains of resp's body, up to a cap, and then
+// closes it so that the underlying connection can be reused by the
+// http.Client. Errors are ignored and it is safe to call with a nil
+// response or a nil body.
+func DrainAndClose(resp *http.Response) {
+	if resp == nil || resp.Body == nil {
+		return
+	}
+	_, _ = io.CopyN(io.Discard, resp.Body, maxDrainBytes)
+	_ = resp.Body.Close()
+}
+
+// CloneRequest returns a deep copy of r that uses ctx as its context.
+// If r has a body, the body is read into memory so that r and the clone
+// each get their own independently readable copy of it, and GetBody is
+// set on both so that the body can be replayed again, e.g. on redirects.
+// Reading r's body consumes and closes the original.
+func CloneRequest(r *http.Request, ctx context.Context) (*http.Request, error) {
+	clone := r.Clone(ctx)
+	if r.Body == nil || r.Body == http.NoBody {
+		return clone, nil
+	}
+
+	body, err := io.ReadAll(r.Body)
+	_ = r.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	getBody := func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	for _, req := range []*http.Request{r, clone} {
+		req.Body, _ = getBody()
+		req.GetBody = getBody
+		req.ContentLength = int64(len(body))
+	}
+	return clone, nil
+}
+
+// IsSafeMethod reports whether method is one of the HTTP methods
+// that RFC 7231 defines as safe, that is read-only: GET, HEAD, OPTIONS
+// and TRACE. The comparison is case-insensitive.
+func IsSafeMethod(method string) bool {
+	switch strings.ToUpper(method) {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsIdempotentMethod reports whether method is one of the HTTP methods
+// that RFC 7231 defines as idempotent, which are the safe methods plus
+// PUT and DELETE. The comparison is case-insensitive.
+func IsIdempotentMethod(method string) bool {
+	switch strings.ToUpper(method) {
+	case http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return IsSafeMethod(method)
+	}
+}
+
+// IsHTTP2 reports whether r was received over HTTP/2, according to
+// the ProtoMajor that the server set.
+func IsHTTP2(r *http.Request) bool {
+	return r.ProtoMajor == 2
+}
+
+// ProtocolVersion returns the protocol version of r in a normalized form
+// such as "HTTP/1.0", "HTTP/1.1" or "HTTP/2", without a minor version from
+// HTTP/2 on. If ProtoMajor and ProtoMinor are unset, as with hand built
+// requests, they are parsed from r.Proto, and "" is returned if that fails.
+func ProtocolVersion(r *http.Request) string {
+	major, minor := r.ProtoMajor, r.ProtoMinor
+	if major == 0 && minor == 0 {
+		var ok bool
+		if major, minor, ok = http.ParseHTTPVersion(r.Proto); !ok {
+			return ""
+		}
+	}
+	if major >= 2 {
+		return "HTTP/" + strconv.Itoa(major)
+	}
+	return fmt.Sprintf("HTTP/%d.%d", major, minor)
+}
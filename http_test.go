@@ -0,0 +1,230 @@
+package otils
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestResponseIsEmpty(t *testing.T) {
+	tests := []struct {
+		name     string
+		resp     *http.Response
+		expected bool
+	}{
+		{"nil", nil, true},
+		{"204", &http.Response{StatusCode: 204, ContentLength: -1}, true},
+		{"304", &http.Response{StatusCode: 304, ContentLength: -1}, true},
+		{"200 zero length", &http.Response{StatusCode: 200, ContentLength: 0}, true},
+		{
+			"200 with body",
+			&http.Response{StatusCode: 200, ContentLength: 5, Body: io.NopCloser(strings.NewReader("hello"))},
+			false,
+		},
+		{"200 unknown length", &http.Response{StatusCode: 200, ContentLength: -1}, false},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if got := ResponseIsEmpty(tc.resp); got != tc.expected {
+				t.Errorf("unexpected result, want: %v, got: %v", tc.expected, got)
+			}
+			if tc.resp != nil && tc.resp.Body != nil {
+				body, _ := io.ReadAll(tc.resp.Body)
+				if len(body) != int(tc.resp.ContentLength) {
+					t.Errorf("the body should not have been consumed, got: %q", body)
+				}
+			}
+		})
+	}
+}
+
+func TestPaginationLinkHeader(t *testing.T) {
+	base := "https://api.orijtech.com/items?sort=asc"
+	link := func(page int, rel string) string {
+		return fmt.Sprintf(`<https://api.orijtech.com/items?page=%d&per_page=10&sort=asc>; rel=%q`, page, rel)
+	}
+	tests := []struct {
+		name     string
+		page     int
+		expected string
+	}{
+		{"first page", 1, strings.Join([]string{link(1, "first"), link(2, "next"), link(5, "last")}, ", ")},
+		{"middle page", 3, strings.Join([]string{link(1, "first"), link(2, "prev"), link(4, "next"), link(5, "last")}, ", ")},
+		{"last page", 5, strings.Join([]string{link(1, "first"), link(4, "prev"), link(5, "last")}, ", ")},
+		{"past the last page", 9, strings.Join([]string{link(1, "first"), link(4, "prev"), link(5, "last")}, ", ")},
+		{"before the first page", 0, strings.Join([]string{link(1, "first"), link(2, "next"), link(5, "last")}, ", ")},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if got := PaginationLinkHeader(base, tc.page, 10, 5); got != tc.expected {
+				t.Errorf("unexpected result\nwant: %s\ngot:  %s", tc.expected, got)
+			}
+		})
+	}
+
+	if got := PaginationLinkHeader(base, 1, 10, 0); got != "" {
+		t.Errorf("expected no links without pages, got: %q", got)
+	}
+}
+
+func TestDrainAndClose(t *testing.T) {
+	// No panics expected for nils at any level.
+	DrainAndClose(nil)
+	DrainAndClose(&http.Response{})
+
+	tst := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		_, _ = io.WriteString(rw, strings.Repeat("a", 1<<10))
+	}))
+	defer tst.Close()
+
+	client := tst.Client()
+	var reused []bool
+	for i := 0; i < 2; i++ {
+		trace := &httptrace.ClientTrace{
+			GotConn: func(info httptrace.GotConnInfo) { reused = append(reused, info.Reused) },
+		}
+		req, err := http.NewRequest("GET", tst.URL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		DrainAndClose(resp)
+	}
+
+	if want := []bool{false, true}; !reflect.DeepEqual(reused, want) {
+		t.Errorf("unexpected connection reuse, want: %v, got: %v", want, reused)
+	}
+}
+
+func TestCloneRequest(t *testing.T) {
+	type ctxKey struct{}
+	ctx := context.WithValue(context.Background(), ctxKey{}, "clone")
+
+	req := httptest.NewRequest("POST", "/items?x=1", strings.NewReader("payload"))
+	req.Header.Set("X-Trace", "abc")
+	clone, err := CloneRequest(req, ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := clone.Context().Value(ctxKey{}); got != "clone" {
+		t.Errorf("the clone should use the provided context, got value: %v", got)
+	}
+	clone.Header.Set("X-Trace", "changed")
+	if got := req.Header.Get("X-Trace"); got != "abc" {
+		t.Errorf("headers should be cloned independently, got: %q", got)
+	}
+
+	for name, r := range map[string]*http.Request{"original": req, "clone": clone} {
+		for i := 0; i < 2; i++ {
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			if got := string(body); got != "payload" {
+				t.Errorf("%s: unexpected body, want: %q, got: %q", name, "payload", got)
+			}
+			// Replay the body for the next round.
+			if r.Body, err = r.GetBody(); err != nil {
+				t.Fatalf("%s: GetBody: %v", name, err)
+			}
+		}
+		if r.ContentLength != int64(len("payload")) {
+			t.Errorf("%s: unexpected ContentLength: %d", name, r.ContentLength)
+		}
+	}
+}
+
+func TestCloneRequestNoBody(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	clone, err := CloneRequest(req, context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if clone.Body != req.Body {
+		t.Errorf("a request without a body should keep it as is")
+	}
+}
+
+func TestIdempotentAndSafeMethods(t *testing.T) {
+	tests := []struct {
+		method     string
+		idempotent bool
+		safe       bool
+	}{
+		{"GET", true, true},
+		{"HEAD", true, true},
+		{"OPTIONS", true, true},
+		{"TRACE", true, true},
+		{"PUT", true, false},
+		{"DELETE", true, false},
+		{"POST", false, false},
+		{"PATCH", false, false},
+		{"CONNECT", false, false},
+		{"get", true, true},
+		{"delete", true, false},
+		{"post", false, false},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.method, func(t *testing.T) {
+			t.Parallel()
+			if got := IsIdempotentMethod(tc.method); got != tc.idempotent {
+				t.Errorf("IsIdempotentMethod: want: %v, got: %v", tc.idempotent, got)
+			}
+			if got := IsSafeMethod(tc.method); got != tc.safe {
+				t.Errorf("IsSafeMethod: want: %v, got: %v", tc.safe, got)
+			}
+		})
+	}
+}
+
+func TestProtocolVersion(t *testing.T) {
+	tests := []struct {
+		name     string
+		proto    string
+		major    int
+		minor    int
+		expected string
+		http2    bool
+	}{
+		{"http 1.0", "HTTP/1.0", 1, 0, "HTTP/1.0", false},
+		{"http 1.1", "HTTP/1.1", 1, 1, "HTTP/1.1", false},
+		{"http 2", "HTTP/2.0", 2, 0, "HTTP/2", true},
+		{"http 3", "HTTP/3.0", 3, 0, "HTTP/3", false},
+		{"only proto set", "HTTP/1.1", 0, 0, "HTTP/1.1", false},
+		{"only proto set for http 2", "HTTP/2.0", 0, 0, "HTTP/2", false},
+		{"nothing set", "", 0, 0, "", false},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			req := &http.Request{Proto: tc.proto, ProtoMajor: tc.major, ProtoMinor: tc.minor}
+			if got := ProtocolVersion(req); got != tc.expected {
+				t.Errorf("ProtocolVersion: want: %q, got: %q", tc.expected, got)
+			}
+			if got := IsHTTP2(req); got != tc.http2 {
+				t.Errorf("IsHTTP2: want: %v, got: %v", tc.http2, got)
+			}
+		})
+	}
+}
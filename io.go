@@ -0,0 +1,70 @@
+package otils
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"time"
+)
+
+// ErrLimitExceeded is returned when more data was
+// available than the limit that was set for it.
+var ErrLimitExceeded = errors.New("limit exceeded")
+
+// CopyN copies at most limit bytes from src to dst. Unlike io.CopyN,
+// a source that ends before limit bytes is not an error. Instead, if src
+// still has data after limit bytes have been copied, ErrLimitExceeded
+// is returned, which is detected by probing src for one more byte.
+func CopyN(dst io.Writer, src io.Reader, limit int64) (written int64, err error) {
+	written, err = io.CopyN(dst, src, limit)
+	if err == io.EOF {
+		return written, nil
+	}
+	if err != nil {
+		return written, err
+	}
+
+	var probe [1]byte
+	switch n, err := io.ReadFull(src, probe[:]); {
+	case n > 0:
+		return written, ErrLimitExceeded
+	case err == io.EOF:
+		return written, nil
+	default:
+		return written, err
+	}
+}
+
+// ReadAllWithDeadline reads from r until EOF like io.ReadAll, except that it
+// gives up with os.ErrDeadlineExceeded if reading takes longer than d and with
+// ErrLimitExceeded, along with the first limit bytes, if r has more than limit
+// bytes. Reading happens on another goroutine so that a blocked Read can be
+// abandoned. If r is also an io.Closer it is closed on timeout to unblock that
+// goroutine, otherwise the goroutine exits once the pending Read returns.
+func ReadAllWithDeadline(r io.Reader, d time.Duration, limit int64) ([]byte, error) {
+	type result struct {
+		data []byte
+		err  error
+	}
+	// Buffered so that the goroutine never blocks on sending
+	// a result that nobody is waiting for anymore.
+	resultCh := make(chan result, 1)
+	go func() {
+		buf := new(bytes.Buffer)
+		_, err := CopyN(buf, r, limit)
+		resultCh <- result{data: buf.Bytes(), err: err}
+	}()
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case res := <-resultCh:
+		return res.data, res.err
+	case <-timer.C:
+		if closer, ok := r.(io.Closer); ok {
+			_ = closer.Close()
+		}
+		return nil, os.ErrDeadlineExceeded
+	}
+}
@@ -0,0 +1,98 @@
+package otils
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCopyN(t *testing.T) {
+	tests := []struct {
+		name        string
+		src         string
+		limit       int64
+		wantWritten int64
+		wantErr     error
+	}{
+		{"under limit", "abc", 5, 3, nil},
+		{"exactly at limit", "abcde", 5, 5, nil},
+		{"over limit", "abcdefgh", 5, 5, ErrLimitExceeded},
+		{"empty source", "", 5, 0, nil},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			dst := new(bytes.Buffer)
+			written, err := CopyN(dst, strings.NewReader(tc.src), tc.limit)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("unexpected error, want: %v, got: %v", tc.wantErr, err)
+			}
+			if written != tc.wantWritten {
+				t.Errorf("unexpected written count, want: %d, got: %d", tc.wantWritten, written)
+			}
+			if want := tc.src[:written]; dst.String() != want {
+				t.Errorf("unexpected copied data, want: %q, got: %q", want, dst.String())
+			}
+		})
+	}
+}
+
+// slowReader blocks every Read until it is closed.
+type slowReader struct {
+	closed chan struct{}
+}
+
+func (sr *slowReader) Read(b []byte) (int, error) {
+	<-sr.closed
+	return 0, io.ErrClosedPipe
+}
+
+func (sr *slowReader) Close() error {
+	close(sr.closed)
+	return nil
+}
+
+func TestReadAllWithDeadline(t *testing.T) {
+	t.Run("fast reader", func(t *testing.T) {
+		got, err := ReadAllWithDeadline(strings.NewReader("hello"), time.Second, 10)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if string(got) != "hello" {
+			t.Errorf("unexpected result, want: %q, got: %q", "hello", got)
+		}
+	})
+
+	t.Run("slow reader", func(t *testing.T) {
+		sr := &slowReader{closed: make(chan struct{})}
+		start := time.Now()
+		_, err := ReadAllWithDeadline(sr, 20*time.Millisecond, 10)
+		if !errors.Is(err, os.ErrDeadlineExceeded) {
+			t.Fatalf("unexpected error, want: %v, got: %v", os.ErrDeadlineExceeded, err)
+		}
+		if elapsed := time.Since(start); elapsed > 5*time.Second {
+			t.Errorf("took too long to time out: %v", elapsed)
+		}
+		select {
+		case <-sr.closed:
+		default:
+			t.Error("expected the reader to be closed to unblock the read")
+		}
+	})
+
+	t.Run("oversized reader", func(t *testing.T) {
+		got, err := ReadAllWithDeadline(strings.NewReader("hello world"), time.Second, 5)
+		if !errors.Is(err, ErrLimitExceeded) {
+			t.Fatalf("unexpected error, want: %v, got: %v", ErrLimitExceeded, err)
+		}
+		if string(got) != "hello" {
+			t.Errorf("unexpected result, want: %q, got: %q", "hello", got)
+		}
+	})
+}
@@ -0,0 +1,171 @@
+package otils
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// ClientIP returns the IP address of the client that made r. When the
+// immediate peer, r.RemoteAddr, is one of trustedProxies, the X-Forwarded-For
+// chain is walked from right to left and the first address that isn't a
+// trusted proxy is returned, so entries prepended by the client itself are
+// never trusted. Otherwise the IP of r.RemoteAddr is returned. Each of
+// trustedProxies can be either an IP address or a CIDR range. Ports are
+// stripped from all addresses.
+func ClientIP(r *http.Request, trustedProxies []string) string {
+	trusted := parseTrustedProxies(trustedProxies)
+	addr := stripPort(r.RemoteAddr)
+	if !trusted.contains(net.ParseIP(addr)) {
+		return addr
+	}
+
+	var hops []string
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(header, ",")...)
+	}
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := stripPort(strings.TrimSpace(hops[i]))
+		ip := net.ParseIP(hop)
+		if ip == nil {
+			// A malformed entry can't be trusted to be an
+			// address so the last proxy seen stands in for it.
+			break
+		}
+		addr = hop
+		if !trusted.contains(ip) {
+			break
+		}
+	}
+	return addr
+}
+
+type ipNets []*net.IPNet
+
+func parseTrustedProxies(proxies []string) (nets ipNets) {
+	for _, proxy := range proxies {
+		proxy = strings.TrimSpace(proxy)
+		if _, ipNet, err := net.ParseCIDR(proxy); err == nil {
+			nets = append(nets, ipNet)
+			continue
+		}
+		if ip := net.ParseIP(proxy); ip != nil {
+			bits := 8 * net.IPv6len
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 8*net.IPv4len
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+		}
+	}
+	return nets
+}
+
+func (nets ipNets) contains(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+	for _, ipNet := range nets {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// stripPort returns addr without its port, if any,
+// and without the brackets around IPv6 addresses.
+func stripPort(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(addr, "["), "]")
+}
+
+// ForwardedElement holds the parameters of one element of a Forwarded
+// header, as defined by RFC 7239. Quoted values are unquoted, but node
+// identifiers are otherwise returned as sent, so For and By may be an
+// obfuscated identifier such as "_hidden", "unknown", or carry a port
+// like "[2001:db8::1]:4711" that net.SplitHostPort can separate.
+type ForwardedElement struct {
+	For   string
+	By    string
+	Host  string
+	Proto string
+}
+
+// ParseForwarded parses the value of a Forwarded header into its
+// comma-separated elements, in order. Parameter names are matched
+// regardless of case, unknown parameters are ignored, and separators
+// inside quoted strings don't split values. Elements without any
+// recognized parameter are omitted.
+func ParseForwarded(header string) []ForwardedElement {
+	var elements []ForwardedElement
+	for _, element := range splitQuoted(header, ',') {
+		var fe ForwardedElement
+		var found bool
+		for _, pair := range splitQuoted(element, ';') {
+			i := strings.IndexByte(pair, '=')
+			if i < 0 {
+				continue
+			}
+			name := strings.ToLower(strings.TrimSpace(pair[:i]))
+			value := unquoteForwarded(strings.TrimSpace(pair[i+1:]))
+			switch name {
+			case "for":
+				fe.For = value
+			case "by":
+				fe.By = value
+			case "host":
+				fe.Host = value
+			case "proto":
+				fe.Proto = value
+			default:
+				continue
+			}
+			found = true
+		}
+		if found {
+			elements = append(elements, fe)
+		}
+	}
+	return elements
+}
+
+// splitQuoted splits s around each instance of sep
+// that isn't inside a quoted string.
+func splitQuoted(s string, sep byte) []string {
+	var parts []string
+	var quoted, escaped bool
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case escaped:
+			escaped = false
+		case quoted && c == '\\':
+			escaped = true
+		case c == '"':
+			quoted = !quoted
+		case !quoted && c == sep:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// unquoteForwarded removes the quotes around a quoted-string value
+// and resolves its backslash escapes. Other values are returned as is.
+func unquoteForwarded(value string) string {
+	if len(value) < 2 || value[0] != '"' || value[len(value)-1] != '"' {
+		return value
+	}
+	value = value[1 : len(value)-1]
+	var sb strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] == '\\' && i+1 < len(value) {
+			i++
+		}
+		sb.WriteByte(value[i])
+	}
+	return sb.String()
+}
@@ -0,0 +1,102 @@
+package otils
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	trusted := []string{"10.0.0.1", "192.168.0.0/16"}
+	tests := []struct {
+		name       string
+		remoteAddr string
+		xff        []string
+		expected   string
+	}{
+		{"direct connection", "203.0.113.7:51234", nil, "203.0.113.7"},
+		{"direct connection ignores forwarded for", "203.0.113.7:51234", []string{"198.51.100.1"}, "203.0.113.7"},
+		{"single trusted proxy", "10.0.0.1:80", []string{"198.51.100.1"}, "198.51.100.1"},
+		{
+			"spoofed entries before the real client",
+			"10.0.0.1:80",
+			[]string{"1.1.1.1, 2.2.2.2", "198.51.100.1, 192.168.1.5"},
+			"198.51.100.1",
+		},
+		{"forwarded for with port", "10.0.0.1:80", []string{"198.51.100.1:4711"}, "198.51.100.1"},
+		{"ipv6 client", "[192.168.3.3]:80", []string{"[2001:db8::1]:443"}, "2001:db8::1"},
+		{"only trusted proxies", "10.0.0.1:80", []string{"192.168.1.1"}, "192.168.1.1"},
+		{"malformed entry", "10.0.0.1:80", []string{"garbage, 192.168.1.9"}, "192.168.1.9"},
+		{"trusted proxy without forwarded for", "10.0.0.1:80", nil, "10.0.0.1"},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			req := httptest.NewRequest("GET", "/", nil)
+			req.RemoteAddr = tc.remoteAddr
+			for _, xff := range tc.xff {
+				req.Header.Add("X-Forwarded-For", xff)
+			}
+			if got := ClientIP(req, trusted); got != tc.expected {
+				t.Errorf("unexpected result, want: %q, got: %q", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestParseForwarded(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   string
+		expected []ForwardedElement
+	}{
+		{"empty", "", nil},
+		{
+			"single element",
+			"for=192.0.2.60;proto=http;by=203.0.113.43;host=example.com",
+			[]ForwardedElement{{For: "192.0.2.60", By: "203.0.113.43", Host: "example.com", Proto: "http"}},
+		},
+		{
+			"multiple elements",
+			"for=192.0.2.43, for=198.51.100.17;proto=https",
+			[]ForwardedElement{{For: "192.0.2.43"}, {For: "198.51.100.17", Proto: "https"}},
+		},
+		{
+			"case insensitive names",
+			"For=192.0.2.43;PROTO=https",
+			[]ForwardedElement{{For: "192.0.2.43", Proto: "https"}},
+		},
+		{
+			"quoted ipv6 with port",
+			`for="[2001:db8:cafe::17]:4711"`,
+			[]ForwardedElement{{For: "[2001:db8:cafe::17]:4711"}},
+		},
+		{
+			"separators inside quotes",
+			`for="_gazonk;a,b";host="example.com", for=unknown`,
+			[]ForwardedElement{{For: "_gazonk;a,b", Host: "example.com"}, {For: "unknown"}},
+		},
+		{
+			"escaped quote",
+			`by="\"proxy\""`,
+			[]ForwardedElement{{By: `"proxy"`}},
+		},
+		{
+			"unknown parameters and blank elements",
+			"secret=x, , for=192.0.2.1;secret=y",
+			[]ForwardedElement{{For: "192.0.2.1"}},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if got := ParseForwarded(tc.header); !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("unexpected result, want: %+v, got: %+v", tc.expected, got)
+			}
+		})
+	}
+}
@@ -2,6 +2,11 @@ package otils
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
 	"strconv"
 	"strings"
 	"time"
@@ -118,3 +123,93 @@ func (nt *NullableTime) UnmarshalJSON(b []byte) error {
 	*nt = NullableTime(*t)
 	return nil
 }
+
+// DecodeJSONOption configures DecodeJSONBody.
+type DecodeJSONOption func(*jsonBodyDecoder)
+
+// AllowUnknownFields makes DecodeJSONBody ignore object keys
+// that don't match any field of the destination, instead of failing.
+func AllowUnknownFields() DecodeJSONOption {
+	return func(jd *jsonBodyDecoder) {
+		jd.allowUnknownFields = true
+	}
+}
+
+type jsonBodyDecoder struct {
+	allowUnknownFields bool
+}
+
+// maxBytesErrorMessage is the message of the error that an http.MaxBytesReader
+// returns once its limit is exceeded. It is matched on since the error isn't
+// exported as a distinct type in all of the Go versions that we support.
+const maxBytesErrorMessage = "http: request body too large"
+
+// DecodeJSONBody decodes the JSON body of r into dst. The request must have a
+// JSON Content-Type, that is "application/json" or a "+json" suffixed type,
+// and a body of at most maxBytes bytes unless maxBytes is less than 1. Unless
+// AllowUnknownFields is passed in, object keys that don't match a field of dst
+// are rejected. The returned errors are *CodedError values whose Code is the
+// status to respond with: 415 for a wrong Content-Type, 413 for a body that is
+// too large and 400 for malformed JSON or unknown fields.
+func DecodeJSONBody(r *http.Request, dst interface{}, maxBytes int64, opts ...DecodeJSONOption) error {
+	jd := new(jsonBodyDecoder)
+	for _, opt := range opts {
+		opt(jd)
+	}
+
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || (mediaType != "application/json" && !strings.HasSuffix(mediaType, "+json")) {
+		msg := fmt.Sprintf("unsupported Content-Type %q, expecting application/json", r.Header.Get("Content-Type"))
+		return MakeCodedError(msg, http.StatusUnsupportedMediaType)
+	}
+	if r.Body == nil {
+		return MakeCodedError("request body is empty", http.StatusBadRequest)
+	}
+
+	body := r.Body
+	if maxBytes > 0 {
+		body = http.MaxBytesReader(nil, body, maxBytes)
+	}
+	dec := json.NewDecoder(body)
+	if !jd.allowUnknownFields {
+		dec.DisallowUnknownFields()
+	}
+	if err := dec.Decode(dst); err != nil {
+		return jsonBodyError(err, maxBytes)
+	}
+	if err := dec.Decode(&struct{}{}); err != io.EOF {
+		if err != nil && err.Error() == maxBytesErrorMessage {
+			return jsonBodyError(err, maxBytes)
+		}
+		return MakeCodedError("request body must contain a single JSON value", http.StatusBadRequest)
+	}
+	return nil
+}
+
+// jsonBodyError converts an error from decoding a
+// JSON request body into a descriptive *CodedError.
+func jsonBodyError(err error, maxBytes int64) error {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case err.Error() == maxBytesErrorMessage:
+		msg := fmt.Sprintf("request body must not be larger than %d bytes", maxBytes)
+		return MakeCodedError(msg, http.StatusRequestEntityTooLarge)
+	case errors.As(err, &syntaxErr):
+		msg := fmt.Sprintf("malformed JSON at offset %d: %v", syntaxErr.Offset, err)
+		return MakeCodedError(msg, http.StatusBadRequest)
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return MakeCodedError("malformed JSON: unexpected end of body", http.StatusBadRequest)
+	case errors.Is(err, io.EOF):
+		return MakeCodedError("request body is empty", http.StatusBadRequest)
+	case errors.As(err, &typeErr):
+		msg := fmt.Sprintf("invalid value for field %q at offset %d", typeErr.Field, typeErr.Offset)
+		return MakeCodedError(msg, http.StatusBadRequest)
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		// encoding/json doesn't export a type for this error.
+		msg := "request body contains unknown field " + strings.TrimPrefix(err.Error(), "json: unknown field ")
+		return MakeCodedError(msg, http.StatusBadRequest)
+	default:
+		return MakeCodedError(err.Error(), http.StatusBadRequest)
+	}
+}
@@ -2,6 +2,10 @@ package otils
 
 import (
 	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 )
@@ -64,3 +68,60 @@ func TestNullableTime(t *testing.T) {
 		})
 	}
 }
+
+func TestDecodeJSONBody(t *testing.T) {
+	type user struct {
+		Name string `json:"name"`
+		Age  int    `json:"age"`
+	}
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		opts        []DecodeJSONOption
+		wantCode    int
+		want        user
+	}{
+		{"success", "application/json", `{"name": "ada", "age": 36}`, nil, 0, user{Name: "ada", Age: 36}},
+		{"charset and suffix", "application/vnd.api+json; charset=utf-8", `{"name": "ada"}`, nil, 0, user{Name: "ada"}},
+		{"wrong content type", "text/plain", `{"name": "ada"}`, nil, http.StatusUnsupportedMediaType, user{}},
+		{"missing content type", "", `{"name": "ada"}`, nil, http.StatusUnsupportedMediaType, user{}},
+		{"too large", "application/json", `{"name": "` + strings.Repeat("a", 64) + `"}`, nil, http.StatusRequestEntityTooLarge, user{}},
+		{"malformed", "application/json", `{"name": "ada",}`, nil, http.StatusBadRequest, user{}},
+		{"truncated", "application/json", `{"name": "ada"`, nil, http.StatusBadRequest, user{}},
+		{"empty", "application/json", ``, nil, http.StatusBadRequest, user{}},
+		{"wrong type", "application/json", `{"age": "old"}`, nil, http.StatusBadRequest, user{}},
+		{"unknown field", "application/json", `{"name": "ada", "admin": true}`, nil, http.StatusBadRequest, user{}},
+		{"unknown field allowed", "application/json", `{"name": "ada", "admin": true}`, []DecodeJSONOption{AllowUnknownFields()}, 0, user{Name: "ada"}},
+		{"multiple values", "application/json", `{"name": "ada"}{"name": "bob"}`, nil, http.StatusBadRequest, user{}},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			req := httptest.NewRequest("POST", "/users", strings.NewReader(tc.body))
+			if tc.contentType != "" {
+				req.Header.Set("Content-Type", tc.contentType)
+			}
+			var got user
+			err := DecodeJSONBody(req, &got, 48, tc.opts...)
+			if tc.wantCode == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if got != tc.want {
+					t.Errorf("unexpected result, want: %+v, got: %+v", tc.want, got)
+				}
+				return
+			}
+			var cerr *CodedError
+			if !errors.As(err, &cerr) {
+				t.Fatalf("expected a *CodedError, got: %v", err)
+			}
+			if cerr.Code() != tc.wantCode {
+				t.Errorf("unexpected code, want: %d, got: %d (%v)", tc.wantCode, cerr.Code(), cerr)
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package otils
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ConcurrencyOption configures the handler returned by LimitConcurrency.
+type ConcurrencyOption func(*concurrencyLimiter)
+
+// RejectWhenBusy makes the handler returned by LimitConcurrency
+// immediately respond with 503 Service Unavailable whenever
+// the limit has been reached, instead of waiting for a free slot.
+func RejectWhenBusy() ConcurrencyOption {
+	return func(cl *concurrencyLimiter) {
+		cl.rejectWhenBusy = true
+	}
+}
+
+type concurrencyLimiter struct {
+	sem            chan struct{}
+	rejectWhenBusy bool
+
+	next http.Handler
+}
+
+// LimitConcurrency caps the number of requests that next serves
+// concurrently to max. By default requests that arrive while the limit
+// has been reached wait for a free slot, or until their context is done.
+// If max is less than 1, next is returned unmodified.
+func LimitConcurrency(max int, next http.Handler, opts ...ConcurrencyOption) http.Handler {
+	if max < 1 {
+		return next
+	}
+	cl := &concurrencyLimiter{
+		sem:  make(chan struct{}, max),
+		next: next,
+	}
+	for _, opt := range opts {
+		opt(cl)
+	}
+	return cl
+}
+
+var _ http.Handler = (*concurrencyLimiter)(nil)
+
+func (cl *concurrencyLimiter) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	if cl.rejectWhenBusy {
+		select {
+		case cl.sem <- struct{}{}:
+		default:
+			http.Error(rw, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+			return
+		}
+	} else {
+		select {
+		case cl.sem <- struct{}{}:
+		case <-req.Context().Done():
+			// The request was cancelled before a slot freed
+			// up so there is nobody to serve the response to.
+			return
+		}
+	}
+	defer func() { <-cl.sem }()
+
+	cl.next.ServeHTTP(rw, req)
+}
+
+// tokenBucket holds the tokens left for a key as of last.
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+type rateLimiter struct {
+	rps   float64
+	burst float64
+	keyFn func(*http.Request) string
+	now   func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+
+	next http.Handler
+}
+
+// RateLimit limits each client, as identified by keyFn, to rps requests per
+// second on average with bursts of up to burst requests, using a token bucket
+// per key. Requests over the limit get a 429 Too Many Requests response with a
+// Retry-After header instead of being passed to next. Keys that have been idle
+// long enough for their bucket to refill are evicted to bound memory use.
+// If rps is not positive or burst is less than 1, next is returned unmodified.
+func RateLimit(rps float64, burst int, keyFn func(*http.Request) string, next http.Handler) http.Handler {
+	if rps <= 0 || burst < 1 {
+		return next
+	}
+	return newRateLimiter(rps, burst, keyFn, next, time.Now)
+}
+
+func newRateLimiter(rps float64, burst int, keyFn func(*http.Request) string, next http.Handler, now func() time.Time) *rateLimiter {
+	return &rateLimiter{
+		rps:       rps,
+		burst:     float64(burst),
+		keyFn:     keyFn,
+		now:       now,
+		buckets:   make(map[string]*tokenBucket),
+		lastSweep: now(),
+		next:      next,
+	}
+}
+
+var _ http.Handler = (*rateLimiter)(nil)
+
+func (rl *rateLimiter) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	if wait, ok := rl.allow(rl.keyFn(req)); !ok {
+		retryAfter := int(math.Ceil(wait.Seconds()))
+		if retryAfter < 1 {
+			retryAfter = 1
+		}
+		rw.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+		http.Error(rw, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+		return
+	}
+	rl.next.ServeHTTP(rw, req)
+}
+
+// allow takes a token from the bucket of key, reporting false
+// and how long until one is available if the bucket is empty.
+func (rl *rateLimiter) allow(key string) (wait time.Duration, ok bool) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	now := rl.now()
+	rl.evictIdle(now)
+
+	bucket, ok := rl.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: rl.burst, last: now}
+		rl.buckets[key] = bucket
+	}
+	bucket.tokens += now.Sub(bucket.last).Seconds() * rl.rps
+	if bucket.tokens > rl.burst {
+		bucket.tokens = rl.burst
+	}
+	bucket.last = now
+
+	if bucket.tokens < 1 {
+		return time.Duration((1 - bucket.tokens) / rl.rps * float64(time.Second)), false
+	}
+	bucket.tokens--
+	return 0, true
+}
+
+// evictIdle removes the buckets that would have refilled by now, which
+// is lossless since a missing bucket is recreated full. The sweep runs
+// at most once per refill period to keep its cost amortized.
+func (rl *rateLimiter) evictIdle(now time.Time) {
+	refill := time.Duration(rl.burst / rl.rps * float64(time.Second))
+	if now.Sub(rl.lastSweep) < refill {
+		return
+	}
+	for key, bucket := range rl.buckets {
+		if now.Sub(bucket.last) >= refill {
+			delete(rl.buckets, key)
+		}
+	}
+	rl.lastSweep = now
+}
@@ -0,0 +1,207 @@
+package otils
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// blockingHandler tracks how many requests it is concurrently
+// serving and blocks each of them until release is closed.
+type blockingHandler struct {
+	inflight int32
+	peak     int32
+	served   int32
+	release  chan struct{}
+}
+
+func (bh *blockingHandler) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	n := atomic.AddInt32(&bh.inflight, 1)
+	for {
+		peak := atomic.LoadInt32(&bh.peak)
+		if n <= peak || atomic.CompareAndSwapInt32(&bh.peak, peak, n) {
+			break
+		}
+	}
+	<-bh.release
+	atomic.AddInt32(&bh.inflight, -1)
+	atomic.AddInt32(&bh.served, 1)
+}
+
+func (bh *blockingHandler) waitForInflight(t *testing.T, n int32) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for atomic.LoadInt32(&bh.inflight) < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %d inflight requests", n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestLimitConcurrencyWaits(t *testing.T) {
+	bh := &blockingHandler{release: make(chan struct{})}
+	handler := LimitConcurrency(2, bh)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+		}()
+	}
+
+	bh.waitForInflight(t, 2)
+	// Give the waiting requests a chance to wrongly enter the handler.
+	time.Sleep(20 * time.Millisecond)
+	close(bh.release)
+	wg.Wait()
+
+	if got, want := atomic.LoadInt32(&bh.peak), int32(2); got != want {
+		t.Errorf("peak concurrency: got %d want %d", got, want)
+	}
+	if got, want := atomic.LoadInt32(&bh.served), int32(10); got != want {
+		t.Errorf("served requests: got %d want %d", got, want)
+	}
+}
+
+func TestLimitConcurrencyRejectWhenBusy(t *testing.T) {
+	bh := &blockingHandler{release: make(chan struct{})}
+	handler := LimitConcurrency(1, bh, RejectWhenBusy())
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	}()
+	bh.waitForInflight(t, 1)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if got, want := rec.Code, http.StatusServiceUnavailable; got != want {
+		t.Errorf("status code: got %d want %d", got, want)
+	}
+
+	close(bh.release)
+	<-done
+	if got, want := atomic.LoadInt32(&bh.served), int32(1); got != want {
+		t.Errorf("served requests: got %d want %d", got, want)
+	}
+}
+
+func TestLimitConcurrencyCancelledWhileWaiting(t *testing.T) {
+	bh := &blockingHandler{release: make(chan struct{})}
+	handler := LimitConcurrency(1, bh)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	}()
+	bh.waitForInflight(t, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req := httptest.NewRequest("GET", "/", nil).WithContext(ctx)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	close(bh.release)
+	<-done
+	if got, want := atomic.LoadInt32(&bh.served), int32(1); got != want {
+		t.Errorf("served requests: got %d want %d", got, want)
+	}
+}
+
+func TestLimitConcurrencyNonPositiveMax(t *testing.T) {
+	bh := new(blockingHandler)
+	if got := LimitConcurrency(0, bh); got != http.Handler(bh) {
+		t.Errorf("expected the next handler to be returned unmodified, got %#v", got)
+	}
+}
+
+// fakeClock is a manually advanced clock for tests.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (fc *fakeClock) Now() time.Time {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	return fc.now
+}
+
+func (fc *fakeClock) Advance(d time.Duration) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	fc.now = fc.now.Add(d)
+}
+
+func TestRateLimit(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1600000000, 0)}
+	keyFn := func(req *http.Request) string { return req.Header.Get("X-Client") }
+	ok := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {})
+	rl := newRateLimiter(2, 3, keyFn, ok, clock.Now)
+
+	do := func(client string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("X-Client", client)
+		rec := httptest.NewRecorder()
+		rl.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// The burst is served right away.
+	for i := 0; i < 3; i++ {
+		if rec := do("a"); rec.Code != http.StatusOK {
+			t.Fatalf("request %d: unexpected status, want: %d, got: %d", i, http.StatusOK, rec.Code)
+		}
+	}
+	rec := do("a")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("unexpected status, want: %d, got: %d", http.StatusTooManyRequests, rec.Code)
+	}
+	if got, want := rec.Header().Get("Retry-After"), "1"; got != want {
+		t.Errorf("unexpected Retry-After, want: %q, got: %q", want, got)
+	}
+
+	// Other keys have their own buckets.
+	if rec := do("b"); rec.Code != http.StatusOK {
+		t.Errorf("unexpected status for another key, want: %d, got: %d", http.StatusOK, rec.Code)
+	}
+
+	// At 2 requests per second, a token is back after half a second.
+	clock.Advance(500 * time.Millisecond)
+	if rec := do("a"); rec.Code != http.StatusOK {
+		t.Errorf("unexpected status after recovery, want: %d, got: %d", http.StatusOK, rec.Code)
+	}
+	if rec := do("a"); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("unexpected status, want: %d, got: %d", http.StatusTooManyRequests, rec.Code)
+	}
+
+	// Idle keys are evicted once their buckets would have refilled.
+	clock.Advance(2 * time.Second)
+	do("c")
+	rl.mu.Lock()
+	n := len(rl.buckets)
+	rl.mu.Unlock()
+	if n != 1 {
+		t.Errorf("unexpected number of buckets after eviction, want: 1, got: %d", n)
+	}
+}
+
+func TestRateLimitDisabled(t *testing.T) {
+	next := &blockingHandler{release: make(chan struct{})}
+	keyFn := func(req *http.Request) string { return "" }
+	if got := RateLimit(0, 1, keyFn, next); got != http.Handler(next) {
+		t.Errorf("expected next to be returned for a zero rate, got: %T", got)
+	}
+	if got := RateLimit(1, 0, keyFn, next); got != http.Handler(next) {
+		t.Errorf("expected next to be returned for a zero burst, got: %T", got)
+	}
+}
@@ -0,0 +1,107 @@
+package otils
+
+import (
+	"io"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"strings"
+)
+
+// hopByHopHeaders are the headers that only apply to a single connection
+// and so mustn't be forwarded by proxies, per RFC 7230 section 6.1.
+var hopByHopHeaders = []string{
+	"Connection",
+	"Proxy-Connection",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+// ProxyTo forwards r to the upstream base URL, e.g. "http://10.0.0.2:8080/api",
+// and copies the upstream response's status, headers and body to rw. The path
+// of r is appended to that of upstream and their queries are combined. The
+// hop-by-hop headers are removed in both directions and the client's address
+// is appended to X-Forwarded-For. Redirects aren't followed. If upstream can't
+// be reached, nothing is written to rw and the error is returned so that the
+// caller can respond, for example with 502 Bad Gateway.
+func ProxyTo(upstream string, rw http.ResponseWriter, r *http.Request) error {
+	target, err := url.Parse(upstream)
+	if err != nil {
+		return err
+	}
+
+	outReq := r.Clone(r.Context())
+	outReq.RequestURI = ""
+	outReq.Host = ""
+	outReq.URL = &url.URL{
+		Scheme:   target.Scheme,
+		Host:     target.Host,
+		Path:     singleJoiningSlash(target.Path, r.URL.Path),
+		RawQuery: joinRawQueries(target.RawQuery, r.URL.RawQuery),
+	}
+	if r.ContentLength == 0 {
+		outReq.Body = nil
+	}
+	removeHopByHopHeaders(outReq.Header)
+	if clientIP := stripPort(r.RemoteAddr); clientIP != "" {
+		if prior := outReq.Header.Values("X-Forwarded-For"); len(prior) > 0 {
+			clientIP = strings.Join(prior, ", ") + ", " + clientIP
+		}
+		outReq.Header.Set("X-Forwarded-For", clientIP)
+	}
+
+	res, err := http.DefaultTransport.RoundTrip(outReq)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	removeHopByHopHeaders(res.Header)
+	header := rw.Header()
+	for key, values := range res.Header {
+		header[key] = append([]string(nil), values...)
+	}
+	rw.WriteHeader(res.StatusCode)
+	_, err = io.Copy(rw, res.Body)
+	return err
+}
+
+// removeHopByHopHeaders deletes the hop-by-hop headers from h,
+// including any others that its Connection header lists.
+func removeHopByHopHeaders(h http.Header) {
+	for _, value := range h.Values("Connection") {
+		for _, name := range strings.Split(value, ",") {
+			if name = textproto.TrimString(name); name != "" {
+				h.Del(name)
+			}
+		}
+	}
+	for _, name := range hopByHopHeaders {
+		h.Del(name)
+	}
+}
+
+// singleJoiningSlash joins a and b with exactly one slash between them.
+func singleJoiningSlash(a, b string) string {
+	switch aSlash, bSlash := strings.HasSuffix(a, "/"), strings.HasPrefix(b, "/"); {
+	case aSlash && bSlash:
+		return a + b[1:]
+	case !aSlash && !bSlash && b != "":
+		return a + "/" + b
+	default:
+		return a + b
+	}
+}
+
+// joinRawQueries combines two raw query strings.
+func joinRawQueries(a, b string) string {
+	if a == "" || b == "" {
+		return a + b
+	}
+	return a + "&" + b
+}
@@ -0,0 +1,119 @@
+package otils
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestProxyTo(t *testing.T) {
+	type received struct {
+		method, path, query string
+		body                string
+		header              http.Header
+	}
+	recvCh := make(chan received, 1)
+	upstream := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		recvCh <- received{
+			method: req.Method,
+			path:   req.URL.Path,
+			query:  req.URL.RawQuery,
+			body:   string(body),
+			header: req.Header.Clone(),
+		}
+		rw.Header().Set("X-Upstream", "yes")
+		rw.Header().Set("Keep-Alive", "timeout=5")
+		rw.WriteHeader(http.StatusCreated)
+		rw.Write([]byte("created"))
+	}))
+	defer upstream.Close()
+
+	errCh := make(chan error, 1)
+	gateway := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		errCh <- ProxyTo(upstream.URL+"/api/?key=1", rw, req)
+	}))
+	defer gateway.Close()
+
+	req, err := http.NewRequest("POST", gateway.URL+"/users?page=2", strings.NewReader(`{"name":"ada"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Forwarded-For", "203.0.113.7")
+	req.Header.Set("Proxy-Authorization", "Basic c2VjcmV0")
+	req.Header.Set("X-Hop", "drop me")
+	req.Header.Set("Connection", "X-Hop")
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	body, _ := io.ReadAll(res.Body)
+	if err := <-errCh; err != nil {
+		t.Fatalf("unexpected proxy error: %v", err)
+	}
+
+	recv := <-recvCh
+	if recv.method != "POST" || recv.path != "/api/users" || recv.query != "key=1&page=2" {
+		t.Errorf("unexpected upstream request: %s %s?%s", recv.method, recv.path, recv.query)
+	}
+	if recv.body != `{"name":"ada"}` {
+		t.Errorf("unexpected upstream body: %q", recv.body)
+	}
+	if got := recv.header.Get("Content-Type"); got != "application/json" {
+		t.Errorf("expected end-to-end headers to be forwarded, got Content-Type: %q", got)
+	}
+	for _, name := range []string{"Proxy-Authorization", "X-Hop"} {
+		if got := recv.header.Get(name); got != "" {
+			t.Errorf("expected hop-by-hop header %s to be removed, got: %q", name, got)
+		}
+	}
+	if got, want := recv.header.Get("X-Forwarded-For"), "203.0.113.7, 127.0.0.1"; got != want {
+		t.Errorf("unexpected X-Forwarded-For, want: %q, got: %q", want, got)
+	}
+
+	if res.StatusCode != http.StatusCreated || string(body) != "created" {
+		t.Errorf("unexpected response: %d %q", res.StatusCode, body)
+	}
+	if got := res.Header.Get("X-Upstream"); got != "yes" {
+		t.Errorf("expected upstream headers to be copied, got X-Upstream: %q", got)
+	}
+	if got := res.Header.Get("Keep-Alive"); got != "" {
+		t.Errorf("expected hop-by-hop header Keep-Alive to be removed, got: %q", got)
+	}
+}
+
+func TestProxyToUnreachable(t *testing.T) {
+	upstream := httptest.NewServer(http.NotFoundHandler())
+	upstreamURL := upstream.URL
+	upstream.Close()
+
+	rec := httptest.NewRecorder()
+	if err := ProxyTo(upstreamURL, rec, httptest.NewRequest("GET", "/", nil)); err == nil {
+		t.Fatal("expected an error for an unreachable upstream")
+	}
+	if rec.Body.Len() != 0 || len(rec.Header()) != 0 {
+		t.Errorf("expected nothing to be written, got: %v %q", rec.Header(), rec.Body)
+	}
+}
+
+func TestRemoveHopByHopHeaders(t *testing.T) {
+	h := http.Header{
+		"Connection":        {"Keep-Alive, x-scoped", "X-Other"},
+		"Keep-Alive":        {"timeout=5"},
+		"Transfer-Encoding": {"chunked"},
+		"Upgrade":           {"websocket"},
+		"X-Scoped":          {"1"},
+		"X-Other":           {"2"},
+		"Content-Type":      {"text/plain"},
+	}
+	removeHopByHopHeaders(h)
+	want := http.Header{"Content-Type": {"text/plain"}}
+	if !reflect.DeepEqual(h, want) {
+		t.Errorf("unexpected result, want: %v, got: %v", want, h)
+	}
+}
@@ -0,0 +1,125 @@
+package otils
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// QueryString returns the first value for key in values, or def
+// if the key is absent or its value is blank.
+func QueryString(values url.Values, key, def string) string {
+	if value := strings.TrimSpace(values.Get(key)); value != "" {
+		return value
+	}
+	return def
+}
+
+// QueryInt parses the first value for key in values as an integer.
+// It returns def if the key is absent or its value is blank, and
+// an error if the value is present but isn't a valid integer.
+func QueryInt(values url.Values, key string, def int) (int, error) {
+	value := QueryString(values, key, "")
+	if value == "" {
+		return def, nil
+	}
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		return def, fmt.Errorf("invalid integer for query key %q: %w", key, err)
+	}
+	return i, nil
+}
+
+// QueryBool parses the first value for key in values as a boolean.
+// Besides the values accepted by strconv.ParseBool, "yes", "y", "on",
+// "no", "n" and "off" are accepted regardless of case. It returns def
+// if the key is absent or its value is blank, and an error if the value
+// is present but isn't a recognized boolean.
+func QueryBool(values url.Values, key string, def bool) (bool, error) {
+	value := QueryString(values, key, "")
+	if value == "" {
+		return def, nil
+	}
+	b, err := parseBoolLenient(value)
+	if err != nil {
+		return def, fmt.Errorf("invalid boolean for query key %q: %w", key, err)
+	}
+	return b, nil
+}
+
+func parseBoolLenient(s string) (bool, error) {
+	switch strings.ToLower(s) {
+	case "yes", "y", "on":
+		return true, nil
+	case "no", "n", "off":
+		return false, nil
+	}
+	return strconv.ParseBool(s)
+}
+
+// GetCaseInsensitive returns the first value of the key in values that
+// matches key regardless of case, for clients that send "?Page=2" instead
+// of "?page=2". An exact match is preferred, and otherwise the matching keys
+// are considered in sorted order so that the result is deterministic.
+func GetCaseInsensitive(values url.Values, key string) (string, bool) {
+	if vs := values[key]; len(vs) > 0 {
+		return vs[0], true
+	}
+	for _, k := range foldedKeys(values, key) {
+		if vs := values[k]; len(vs) > 0 {
+			return vs[0], true
+		}
+	}
+	return "", false
+}
+
+// GetAllCaseInsensitive returns the values of all the keys in values that
+// match key regardless of case, merged in the order of the sorted keys.
+// It returns nil if there are none.
+func GetAllCaseInsensitive(values url.Values, key string) []string {
+	var merged []string
+	for _, k := range foldedKeys(values, key) {
+		merged = append(merged, values[k]...)
+	}
+	return merged
+}
+
+// foldedKeys returns the sorted keys of values that equal key under case folding.
+func foldedKeys(values url.Values, key string) []string {
+	var keys []string
+	for k := range values {
+		if strings.EqualFold(k, key) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// URLValuesEqual reports whether a and b have the same keys and, for each
+// key, the same values regardless of their order, counting duplicates. A
+// key that is present without values is distinct from an absent key.
+func URLValuesEqual(a, b url.Values) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for key, aValues := range a {
+		bValues, ok := b[key]
+		if !ok || len(aValues) != len(bValues) {
+			return false
+		}
+		counts := make(map[string]int, len(aValues))
+		for _, value := range aValues {
+			counts[value]++
+		}
+		for _, value := range bValues {
+			if counts[value] == 0 {
+				return false
+			}
+			counts[value]--
+		}
+	}
+	return true
+}
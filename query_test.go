@@ -0,0 +1,181 @@
+package otils
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestQueryString(t *testing.T) {
+	values := url.Values{"name": {"gopher"}, "blank": {"  "}}
+	tests := []struct {
+		name     string
+		key      string
+		expected string
+	}{
+		{"absent", "missing", "def"},
+		{"blank", "blank", "def"},
+		{"valid", "name", "gopher"},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if got := QueryString(values, tc.key, "def"); got != tc.expected {
+				t.Errorf("unexpected result, want: %q, got: %q", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestQueryInt(t *testing.T) {
+	values := url.Values{"page": {"3"}, "bad": {"three"}}
+	tests := []struct {
+		name     string
+		key      string
+		expected int
+		wantErr  bool
+	}{
+		{"absent", "missing", 1, false},
+		{"valid", "page", 3, false},
+		{"invalid", "bad", 1, true},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := QueryInt(values, tc.key, 1)
+			if tc.wantErr != (err != nil) {
+				t.Fatalf("unexpected error state, wantErr: %v, got: %v", tc.wantErr, err)
+			}
+			if got != tc.expected {
+				t.Errorf("unexpected result, want: %d, got: %d", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestQueryBool(t *testing.T) {
+	values := url.Values{"on": {"true"}, "yes": {"Yes"}, "off": {"0"}, "bad": {"maybe"}}
+	tests := []struct {
+		name     string
+		key      string
+		expected bool
+		wantErr  bool
+	}{
+		{"absent", "missing", true, false},
+		{"valid", "on", true, false},
+		{"lenient", "yes", true, false},
+		{"numeric", "off", false, false},
+		{"invalid", "bad", true, true},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := QueryBool(values, tc.key, true)
+			if tc.wantErr != (err != nil) {
+				t.Fatalf("unexpected error state, wantErr: %v, got: %v", tc.wantErr, err)
+			}
+			if got != tc.expected {
+				t.Errorf("unexpected result, want: %v, got: %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestGetCaseInsensitive(t *testing.T) {
+	values := url.Values{
+		"Page":     {"2"},
+		"PER_PAGE": {"50"},
+		"sort":     {"name"},
+		"Sort":     {"stars"},
+		"empty":    {},
+	}
+	tests := []struct {
+		name     string
+		key      string
+		expected string
+		wantOK   bool
+	}{
+		{"mixed case", "page", "2", true},
+		{"upper case", "per_page", "50", true},
+		{"exact match preferred", "Sort", "stars", true},
+		{"first sorted key", "SORT", "stars", true},
+		{"no values", "EMPTY", "", false},
+		{"absent", "q", "", false},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			got, ok := GetCaseInsensitive(values, tc.key)
+			if got != tc.expected || ok != tc.wantOK {
+				t.Errorf("unexpected result, want: (%q, %v), got: (%q, %v)", tc.expected, tc.wantOK, got, ok)
+			}
+		})
+	}
+}
+
+func TestGetAllCaseInsensitive(t *testing.T) {
+	values := url.Values{
+		"tag": {"go"},
+		"TAG": {"http"},
+		"Tag": {"url", "query"},
+		"q":   {"otils"},
+	}
+	tests := []struct {
+		name     string
+		key      string
+		expected []string
+	}{
+		{"mixed case", "tag", []string{"http", "url", "query", "go"}},
+		{"single", "Q", []string{"otils"}},
+		{"absent", "page", nil},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if got := GetAllCaseInsensitive(values, tc.key); !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("unexpected result, want: %q, got: %q", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestURLValuesEqual(t *testing.T) {
+	tests := []struct {
+		name     string
+		a, b     url.Values
+		expected bool
+	}{
+		{"both empty", url.Values{}, nil, true},
+		{"identical", url.Values{"q": {"go"}}, url.Values{"q": {"go"}}, true},
+		{"reordered values", url.Values{"tag": {"a", "b", "a"}, "q": {"go"}}, url.Values{"q": {"go"}, "tag": {"a", "a", "b"}}, true},
+		{"differing values", url.Values{"tag": {"a", "b"}}, url.Values{"tag": {"a", "c"}}, false},
+		{"differing duplicates", url.Values{"tag": {"a", "a", "b"}}, url.Values{"tag": {"a", "b", "b"}}, false},
+		{"differing counts", url.Values{"tag": {"a"}}, url.Values{"tag": {"a", "a"}}, false},
+		{"differing keys", url.Values{"q": {"go"}}, url.Values{"query": {"go"}}, false},
+		{"extra key", url.Values{"q": {"go"}}, url.Values{"q": {"go"}, "page": {"2"}}, false},
+		{"empty key vs absent", url.Values{"q": {"go"}, "page": {}}, url.Values{"q": {"go"}}, false},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if got := URLValuesEqual(tc.a, tc.b); got != tc.expected {
+				t.Errorf("unexpected result, want: %v, got: %v", tc.expected, got)
+			}
+			if got := URLValuesEqual(tc.b, tc.a); got != tc.expected {
+				t.Errorf("unexpected result when swapped, want: %v, got: %v", tc.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package otils
+
+import (
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+)
+
+type pathRewriter struct {
+	// prefixes are the keys of rules sorted from the longest
+	// to the shortest so that the longest match wins.
+	prefixes []string
+	rules    map[string]string
+
+	next http.Handler
+}
+
+// RewritePath returns a handler that transparently rewrites the path of each
+// request before passing it to next. If a request's path starts with a key
+// of rules, that prefix is replaced with the corresponding value. When several
+// keys match, the longest one wins. Requests that match no rule are passed
+// through unchanged. Unlike a redirect, the client never sees the rewritten path.
+func RewritePath(rules map[string]string, next http.Handler) http.Handler {
+	pr := &pathRewriter{
+		rules: make(map[string]string, len(rules)),
+		next:  next,
+	}
+	for from, to := range rules {
+		pr.rules[from] = to
+		pr.prefixes = append(pr.prefixes, from)
+	}
+	sort.Slice(pr.prefixes, func(i, j int) bool {
+		return len(pr.prefixes[i]) > len(pr.prefixes[j])
+	})
+	return pr
+}
+
+var _ http.Handler = (*pathRewriter)(nil)
+
+func (pr *pathRewriter) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	for _, from := range pr.prefixes {
+		if strings.HasPrefix(req.URL.Path, from) {
+			pr.next.ServeHTTP(rw, rewriteRequestPath(req, from, pr.rules[from]))
+			return
+		}
+	}
+	pr.next.ServeHTTP(rw, req)
+}
+
+// rewriteRequestPath returns a shallow copy of req whose path prefix from
+// has been replaced with to, keeping URL.Path and URL.RawPath consistent.
+func rewriteRequestPath(req *http.Request, from, to string) *http.Request {
+	r2 := new(http.Request)
+	*r2 = *req
+	r2.URL = new(url.URL)
+	*r2.URL = *req.URL
+	r2.URL.Path = to + strings.TrimPrefix(req.URL.Path, from)
+	if req.URL.RawPath != "" {
+		if n := escapedPrefixLen(req.URL.RawPath, from); n >= 0 {
+			r2.URL.RawPath = to + req.URL.RawPath[n:]
+		} else {
+			// The raw path doesn't start with the prefix at all,
+			// so let URL.EscapedPath recompute it from URL.Path.
+			r2.URL.RawPath = ""
+		}
+	}
+	return r2
+}
+
+// escapedPrefixLen returns the length of the shortest prefix of rawPath that
+// unescapes to prefix, however it was escaped, or -1 if there is none.
+func escapedPrefixLen(rawPath, prefix string) int {
+	if strings.HasPrefix(rawPath, prefix) {
+		return len(prefix)
+	}
+	for i := len(prefix); i <= len(rawPath); i++ {
+		// Unescaping only ever shortens the path, so the
+		// escaped prefix is at least as long as prefix.
+		if unescaped, err := url.PathUnescape(rawPath[:i]); err == nil && unescaped == prefix {
+			return i
+		}
+	}
+	return -1
+}
+
+// MountPrefix returns a handler that serves h only for requests whose path
+// is prefix or lies under it, and responds with 404 Not Found to all other
+// requests. The prefix is matched on path segment boundaries, so "/api" matches
+// "/api" and "/api/users" but not "/apiary". Like http.StripPrefix, the prefix
+// is stripped from both r.URL.Path and r.URL.RawPath before calling h, leaving
+// "/" for a request to the prefix itself.
+func MountPrefix(prefix string, h http.Handler) http.Handler {
+	prefix = strings.TrimSuffix(prefix, "/")
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if prefix == "" {
+			h.ServeHTTP(rw, req)
+			return
+		}
+		if req.URL.Path != prefix && !strings.HasPrefix(req.URL.Path, prefix+"/") {
+			http.NotFound(rw, req)
+			return
+		}
+
+		r2 := rewriteRequestPath(req, prefix, "")
+		if r2.URL.Path == "" {
+			r2.URL.Path = "/"
+		}
+		if r2.URL.RawPath == "" && req.URL.RawPath != "" && r2.URL.Path == "/" {
+			r2.URL.RawPath = "/"
+		}
+		h.ServeHTTP(rw, r2)
+	})
+}
+
+// SanitizePath returns p as a clean, rooted path that can't escape the root,
+// suitable as a filesystem or route key: it always starts with "/", duplicate
+// slashes are collapsed and "." and ".." elements are resolved, with any ".."
+// beyond the root dropped. Backslashes are treated as slashes, as browsers
+// do. A trailing slash is removed, except from the root "/".
+func SanitizePath(p string) string {
+	return path.Clean("/" + strings.ReplaceAll(p, "\\", "/"))
+}
@@ -0,0 +1,139 @@
+package otils
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func echoPathHandler() http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		fmt.Fprintf(rw, "%s %s", req.URL.Path, req.URL.EscapedPath())
+	})
+}
+
+func TestRewritePath(t *testing.T) {
+	rules := map[string]string{
+		"/old":         "/new",
+		"/old/special": "/special",
+		"/legacy/api/": "/api/v2/",
+	}
+	handler := RewritePath(rules, echoPathHandler())
+
+	tests := []struct {
+		name     string
+		path     string
+		expected string
+	}{
+		{"prefix replaced", "/old/items", "/new/items /new/items"},
+		{"longest match wins", "/old/special/items", "/special/items /special/items"},
+		{"no match", "/other/items", "/other/items /other/items"},
+		{"trailing slash rule", "/legacy/api/users", "/api/v2/users /api/v2/users"},
+		{"raw path kept consistent", "/old/a%2Fb", "/new/a/b /new/a%2Fb"},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", tc.path, nil)
+			handler.ServeHTTP(rec, req)
+			if got := rec.Body.String(); got != tc.expected {
+				t.Errorf("unexpected result, want: %q, got: %q", tc.expected, got)
+			}
+			if req.URL.Path == "" || req.URL.RequestURI() != tc.path {
+				t.Errorf("the original request should not be modified, got: %q", req.URL.RequestURI())
+			}
+		})
+	}
+}
+
+func TestMountPrefix(t *testing.T) {
+	handler := MountPrefix("/api/", echoPathHandler())
+
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+		expected   string
+	}{
+		{"matching path", "/api/users/1", http.StatusOK, "/users/1 /users/1"},
+		{"exact prefix", "/api", http.StatusOK, "/ /"},
+		{"exact prefix with slash", "/api/", http.StatusOK, "/ /"},
+		{"prefix boundary", "/apiary", http.StatusNotFound, ""},
+		{"non-matching path", "/web/index.html", http.StatusNotFound, ""},
+		{"raw path preserved", "/api/files/a%2Fb", http.StatusOK, "/files/a/b /files/a%2Fb"},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest("GET", tc.path, nil))
+			if rec.Code != tc.wantStatus {
+				t.Fatalf("unexpected status, want: %d, got: %d", tc.wantStatus, rec.Code)
+			}
+			if tc.wantStatus == http.StatusOK {
+				if got := rec.Body.String(); got != tc.expected {
+					t.Errorf("unexpected result, want: %q, got: %q", tc.expected, got)
+				}
+			}
+		})
+	}
+}
+
+func TestMountPrefixEscapedPrefix(t *testing.T) {
+	// The prefix is escaped in the raw path, which must keep the "%2F".
+	handler := MountPrefix("/a b", echoPathHandler())
+	tests := []struct {
+		name     string
+		path     string
+		expected string
+	}{
+		{"escaped slash preserved", "/a%20b/c%2Fd", "/c/d /c%2Fd"},
+		{"exact prefix", "/a%20b", "/ /"},
+		{"plain raw path", "/a%20b/c", "/c /c"},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest("GET", tc.path, nil))
+			if got := rec.Body.String(); got != tc.expected {
+				t.Errorf("unexpected result, want: %q, got: %q", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestSanitizePath(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		expected string
+	}{
+		{"normal path", "/users/42/profile", "/users/42/profile"},
+		{"traversal", "../../etc", "/etc"},
+		{"rooted traversal", "/static/../../../etc/passwd", "/etc/passwd"},
+		{"duplicate slashes", "//a//b", "/a/b"},
+		{"relative path", "a/./b/", "/a/b"},
+		{"backslashes", `\..\windows\system32`, "/windows/system32"},
+		{"empty", "", "/"},
+		{"root", "/", "/"},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if got := SanitizePath(tc.path); got != tc.expected {
+				t.Errorf("unexpected result, want: %q, got: %q", tc.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package otils
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// ServeGraceful runs srv.ListenAndServe until the process receives SIGINT or
+// SIGTERM, then shuts srv down gracefully, waiting up to shutdownTimeout for
+// in-flight requests to complete. It returns nil on a clean shutdown.
+func ServeGraceful(srv *http.Server, shutdownTimeout time.Duration) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return ServeGracefulContext(ctx, srv, shutdownTimeout)
+}
+
+// ServeGracefulContext is like ServeGraceful except that the shutdown
+// is triggered by ctx being done instead of by a signal.
+func ServeGracefulContext(ctx context.Context, srv *http.Server, shutdownTimeout time.Duration) error {
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		// The server failed before any shutdown, e.g. to listen.
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-errCh; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package otils
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestServeGracefulContext(t *testing.T) {
+	// Reserve an ephemeral port so that the test knows where to connect.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	started, release := make(chan struct{}), make(chan struct{})
+	srv := &http.Server{
+		Addr: addr,
+		Handler: http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			close(started)
+			<-release
+			rw.Write([]byte("done"))
+		}),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- ServeGracefulContext(ctx, srv, 5*time.Second)
+	}()
+
+	// Retry until the server is listening.
+	respCh := make(chan *http.Response, 1)
+	go func() {
+		deadline := time.Now().Add(5 * time.Second)
+		for time.Now().Before(deadline) {
+			resp, err := http.Get("http://" + addr)
+			if err == nil {
+				respCh <- resp
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		respCh <- nil
+	}()
+
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the server to start")
+	}
+
+	// Shut down with a request in flight, which must still complete.
+	cancel()
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+
+	resp := <-respCh
+	if resp == nil {
+		t.Fatal("expected the in-flight request to complete")
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("unexpected status, want: %d, got: %d", http.StatusOK, resp.StatusCode)
+	}
+
+	select {
+	case err := <-errCh:
+		if err != nil {
+			t.Errorf("expected a clean shutdown, got: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the server to shut down")
+	}
+}
+
+func TestServeGracefulContextListenError(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	srv := &http.Server{Addr: ln.Addr().String()}
+	if err := ServeGracefulContext(context.Background(), srv, time.Second); err == nil {
+		t.Error("expected an error for an address that is in use")
+	}
+}
@@ -0,0 +1,130 @@
+package otils
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// ErrFlushNotSupported is returned when streaming to an
+// http.ResponseWriter that doesn't implement http.Flusher.
+var ErrFlushNotSupported = errors.New("response writer does not support flushing")
+
+// SSEWriter writes Server-Sent Events frames to an http.ResponseWriter.
+type SSEWriter struct {
+	rw http.ResponseWriter
+}
+
+// NewSSEWriter returns an SSEWriter that writes to rw, setting the
+// headers for an event stream if the response hasn't been started yet.
+func NewSSEWriter(rw http.ResponseWriter) *SSEWriter {
+	rw.Header().Set("Content-Type", "text/event-stream")
+	rw.Header().Set("Cache-Control", "no-cache")
+	return &SSEWriter{rw: rw}
+}
+
+// Send writes a single event frame and flushes it to the client. The
+// "event:" line is left out if event is empty and each line of data is
+// sent as its own "data:" line. It returns ErrFlushNotSupported, without
+// writing anything, if the underlying writer can't be flushed.
+func (sw *SSEWriter) Send(event, data string) error {
+	flusher, ok := sw.rw.(http.Flusher)
+	if !ok {
+		return ErrFlushNotSupported
+	}
+
+	var frame strings.Builder
+	if event != "" {
+		// Line breaks would terminate the field early.
+		event = strings.NewReplacer("\r", "", "\n", "").Replace(event)
+		frame.WriteString("event: " + event + "\n")
+	}
+	data = strings.ReplaceAll(data, "\r\n", "\n")
+	for _, line := range strings.Split(data, "\n") {
+		frame.WriteString("data: " + line + "\n")
+	}
+	frame.WriteString("\n")
+
+	if _, err := io.WriteString(sw.rw, frame.String()); err != nil {
+		return err
+	}
+	flusher.Flush()
+	return nil
+}
+
+// jsonArrayFlushEvery is how many elements JSONArrayEncoder
+// writes between flushes to the client.
+const jsonArrayFlushEvery = 64
+
+// errJSONArrayClosed is returned when encoding to a closed JSONArrayEncoder.
+var errJSONArrayClosed = errors.New("JSON array encoder is closed")
+
+// JSONArrayEncoder streams the elements of a JSON array to an
+// http.ResponseWriter as they are encoded, instead of buffering
+// the whole array in memory. Close must be called to terminate
+// the array, even if no elements were encoded.
+type JSONArrayEncoder struct {
+	rw      http.ResponseWriter
+	flusher http.Flusher
+
+	n      int
+	closed bool
+}
+
+// NewJSONArrayEncoder returns a JSONArrayEncoder that writes to rw,
+// setting the Content-Type if the response hasn't been started yet.
+// Writes are flushed periodically if rw implements http.Flusher.
+func NewJSONArrayEncoder(rw http.ResponseWriter) *JSONArrayEncoder {
+	rw.Header().Set("Content-Type", "application/json")
+	flusher, _ := rw.(http.Flusher)
+	return &JSONArrayEncoder{rw: rw, flusher: flusher}
+}
+
+// Encode writes the JSON encoding of v as the next element of the array.
+// Nothing is written if v can't be encoded, so the array stays valid.
+func (je *JSONArrayEncoder) Encode(v interface{}) error {
+	if je.closed {
+		return errJSONArrayClosed
+	}
+	blob, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	sep := ","
+	if je.n == 0 {
+		sep = "["
+	}
+	if _, err := io.WriteString(je.rw, sep); err != nil {
+		return err
+	}
+	if _, err := je.rw.Write(blob); err != nil {
+		return err
+	}
+	je.n++
+	if je.flusher != nil && je.n%jsonArrayFlushEvery == 0 {
+		je.flusher.Flush()
+	}
+	return nil
+}
+
+// Close terminates the array, writing "[]" if no elements were
+// encoded, and flushes it. Calling Close more than once is a no-op.
+func (je *JSONArrayEncoder) Close() error {
+	if je.closed {
+		return nil
+	}
+	je.closed = true
+	end := "]"
+	if je.n == 0 {
+		end = "[]"
+	}
+	if _, err := io.WriteString(je.rw, end); err != nil {
+		return err
+	}
+	if je.flusher != nil {
+		je.flusher.Flush()
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+package otils
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSSEWriterSend(t *testing.T) {
+	tests := []struct {
+		name     string
+		event    string
+		data     string
+		expected string
+	}{
+		{"single line", "update", "hello", "event: update\ndata: hello\n\n"},
+		{"multi line", "update", "line 1\nline 2\r\nline 3", "event: update\ndata: line 1\ndata: line 2\ndata: line 3\n\n"},
+		{"no event name", "", "hello", "data: hello\n\n"},
+		{"event name with line breaks", "up\ndate", "x", "event: update\ndata: x\n\n"},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			rec := httptest.NewRecorder()
+			sw := NewSSEWriter(rec)
+			if err := sw.Send(tc.event, tc.data); err != nil {
+				t.Fatal(err)
+			}
+			if got := rec.Body.String(); got != tc.expected {
+				t.Errorf("unexpected frame, want: %q, got: %q", tc.expected, got)
+			}
+			if !rec.Flushed {
+				t.Errorf("expected the frame to be flushed")
+			}
+			if got, want := rec.Header().Get("Content-Type"), "text/event-stream"; got != want {
+				t.Errorf("unexpected Content-Type, want: %q, got: %q", want, got)
+			}
+		})
+	}
+}
+
+// nonFlushingWriter hides the http.Flusher of the wrapped writer.
+type nonFlushingWriter struct {
+	http.ResponseWriter
+}
+
+func TestSSEWriterNoFlusher(t *testing.T) {
+	rec := httptest.NewRecorder()
+	sw := NewSSEWriter(nonFlushingWriter{rec})
+	if err := sw.Send("update", "hello"); err != ErrFlushNotSupported {
+		t.Errorf("unexpected error, want: %v, got: %v", ErrFlushNotSupported, err)
+	}
+	if rec.Body.Len() != 0 {
+		t.Errorf("nothing should have been written, got: %q", rec.Body.String())
+	}
+}
+
+func TestJSONArrayEncoder(t *testing.T) {
+	type item struct {
+		ID int `json:"id"`
+	}
+	many := make([]interface{}, jsonArrayFlushEvery+1)
+	for i := range many {
+		many[i] = i
+	}
+
+	tests := []struct {
+		name     string
+		elements []interface{}
+		expected string
+	}{
+		{"empty", nil, "[]"},
+		{"one", []interface{}{item{ID: 1}}, `[{"id":1}]`},
+		{"several", []interface{}{item{ID: 1}, "two", 3, nil}, `[{"id":1},"two",3,null]`},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			rec := httptest.NewRecorder()
+			je := NewJSONArrayEncoder(rec)
+			for _, element := range tc.elements {
+				if err := je.Encode(element); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := je.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if got := rec.Body.String(); got != tc.expected {
+				t.Errorf("unexpected body, want: %q, got: %q", tc.expected, got)
+			}
+			if got, want := rec.Header().Get("Content-Type"), "application/json"; got != want {
+				t.Errorf("unexpected Content-Type, want: %q, got: %q", want, got)
+			}
+		})
+	}
+
+	t.Run("flushes periodically", func(t *testing.T) {
+		t.Parallel()
+		rec := httptest.NewRecorder()
+		je := NewJSONArrayEncoder(rec)
+		for _, element := range many {
+			if rec.Flushed {
+				break
+			}
+			if err := je.Encode(element); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if !rec.Flushed {
+			t.Errorf("expected the elements to be flushed before Close")
+		}
+		if err := je.Close(); err != nil {
+			t.Fatal(err)
+		}
+		var got []int
+		if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+			t.Fatalf("invalid JSON array %q: %v", rec.Body.String(), err)
+		}
+	})
+
+	t.Run("unencodable element", func(t *testing.T) {
+		t.Parallel()
+		rec := httptest.NewRecorder()
+		je := NewJSONArrayEncoder(rec)
+		if err := je.Encode(1); err != nil {
+			t.Fatal(err)
+		}
+		if err := je.Encode(make(chan int)); err == nil {
+			t.Errorf("expected an error for an unencodable element")
+		}
+		if err := je.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if got, want := rec.Body.String(), "[1]"; got != want {
+			t.Errorf("unexpected body, want: %q, got: %q", want, got)
+		}
+		if err := je.Encode(2); err == nil {
+			t.Errorf("expected an error encoding after Close")
+		}
+	})
+}
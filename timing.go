@@ -0,0 +1,90 @@
+package otils
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// TimeHandler returns a handler that measures how long next takes to serve
+// each request and calls report with that duration and the status code that
+// was sent. report is also called if next panics, with a status of 500 unless
+// one was already written, after which the panic is propagated.
+func TimeHandler(next http.Handler, report func(d time.Duration, status int)) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		sr := &statusRecorder{ResponseWriter: rw}
+		var wrapped http.ResponseWriter = sr
+		if flusher, ok := rw.(http.Flusher); ok {
+			wrapped = &flushingStatusRecorder{statusRecorder: sr, flusher: flusher}
+		}
+		start := time.Now()
+		defer func() {
+			rec := recover()
+			status := sr.status
+			if status == 0 {
+				if rec != nil {
+					status = http.StatusInternalServerError
+				} else {
+					// net/http implicitly sends a 200 OK for handlers
+					// that return without writing anything.
+					status = http.StatusOK
+				}
+			}
+			report(time.Since(start), status)
+			if rec != nil {
+				panic(rec)
+			}
+		}()
+		next.ServeHTTP(wrapped, req)
+	})
+}
+
+// statusRecorder is an http.ResponseWriter that
+// records the status code sent through it.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (sr *statusRecorder) WriteHeader(code int) {
+	if sr.status == 0 {
+		sr.status = code
+	}
+	sr.ResponseWriter.WriteHeader(code)
+}
+
+func (sr *statusRecorder) Write(b []byte) (int, error) {
+	if sr.status == 0 {
+		sr.status = http.StatusOK
+	}
+	return sr.ResponseWriter.Write(b)
+}
+
+// flushingStatusRecorder is a statusRecorder for ResponseWriters that
+// implement http.Flusher, so that wrapped streaming handlers keep working
+// while those that check for flushing support still see its absence.
+type flushingStatusRecorder struct {
+	*statusRecorder
+	flusher http.Flusher
+}
+
+var _ http.Flusher = (*flushingStatusRecorder)(nil)
+
+func (fsr *flushingStatusRecorder) Flush() {
+	if fsr.status == 0 {
+		// Flushing sends the implicit 200 OK header.
+		fsr.status = http.StatusOK
+	}
+	fsr.flusher.Flush()
+}
+
+// WithRequestDeadline returns a shallow copy of r whose context expires
+// after d, or at the deadline that r's context already has if that comes
+// sooner, e.g. to bound a downstream request made while serving r. The
+// returned context is also cancelled when r's context is, or when cancel
+// is called, which must be done to release its resources.
+func WithRequestDeadline(r *http.Request, d time.Duration) (*http.Request, context.CancelFunc) {
+	// context.WithTimeout keeps the parent's deadline if it is sooner.
+	ctx, cancel := context.WithTimeout(r.Context(), d)
+	return r.WithContext(ctx), cancel
+}
@@ -0,0 +1,185 @@
+package otils
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTimeHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		handler    http.HandlerFunc
+		wantStatus int
+		wantPanic  bool
+	}{
+		{
+			name: "explicit status",
+			handler: func(rw http.ResponseWriter, req *http.Request) {
+				time.Sleep(10 * time.Millisecond)
+				rw.WriteHeader(http.StatusTeapot)
+			},
+			wantStatus: http.StatusTeapot,
+		},
+		{
+			name: "implicit status from write",
+			handler: func(rw http.ResponseWriter, req *http.Request) {
+				time.Sleep(10 * time.Millisecond)
+				rw.Write([]byte("ok"))
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "nothing written",
+			handler: func(rw http.ResponseWriter, req *http.Request) {
+				time.Sleep(10 * time.Millisecond)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "panic",
+			handler: func(rw http.ResponseWriter, req *http.Request) {
+				time.Sleep(10 * time.Millisecond)
+				panic("boom")
+			},
+			wantStatus: http.StatusInternalServerError,
+			wantPanic:  true,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			var reported bool
+			var gotDuration time.Duration
+			var gotStatus int
+			handler := TimeHandler(tc.handler, func(d time.Duration, status int) {
+				reported = true
+				gotDuration, gotStatus = d, status
+			})
+
+			func() {
+				defer func() {
+					if rec := recover(); (rec != nil) != tc.wantPanic {
+						t.Errorf("unexpected panic state, want panic: %v, got: %v", tc.wantPanic, rec)
+					}
+				}()
+				handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+			}()
+
+			if !reported {
+				t.Fatal("expected report to be called")
+			}
+			if gotStatus != tc.wantStatus {
+				t.Errorf("unexpected status, want: %d, got: %d", tc.wantStatus, gotStatus)
+			}
+			if gotDuration < 10*time.Millisecond || gotDuration > 5*time.Second {
+				t.Errorf("implausible duration: %v", gotDuration)
+			}
+		})
+	}
+}
+
+func TestTimeHandlerFlusher(t *testing.T) {
+	tests := []struct {
+		name    string
+		flushes bool
+		wantErr error
+	}{
+		{"flushing writer", true, nil},
+		{"non-flushing writer", false, ErrFlushNotSupported},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			var sendErr error
+			handler := TimeHandler(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				sendErr = NewSSEWriter(rw).Send("", "hello")
+			}), func(time.Duration, int) {})
+
+			rec := httptest.NewRecorder()
+			var rw http.ResponseWriter = rec
+			if !tc.flushes {
+				rw = nonFlushingWriter{rec}
+			}
+			handler.ServeHTTP(rw, httptest.NewRequest("GET", "/", nil))
+			if sendErr != tc.wantErr {
+				t.Errorf("unexpected error, want: %v, got: %v", tc.wantErr, sendErr)
+			}
+			if rec.Flushed != tc.flushes {
+				t.Errorf("unexpected flushing, want: %v, got: %v", tc.flushes, rec.Flushed)
+			}
+		})
+	}
+}
+
+func TestWithRequestDeadline(t *testing.T) {
+	tests := []struct {
+		name          string
+		parentTimeout time.Duration
+		d             time.Duration
+		expected      time.Duration
+	}{
+		{"no parent deadline", 0, time.Minute, time.Minute},
+		{"sooner than parent", time.Hour, time.Minute, time.Minute},
+		{"parent is sooner", time.Minute, time.Hour, time.Minute},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			req := httptest.NewRequest("GET", "/", nil)
+			if tc.parentTimeout > 0 {
+				ctx, cancel := context.WithTimeout(req.Context(), tc.parentTimeout)
+				defer cancel()
+				req = req.WithContext(ctx)
+			}
+
+			start := time.Now()
+			child, cancel := WithRequestDeadline(req, tc.d)
+			defer cancel()
+			deadline, ok := child.Context().Deadline()
+			if !ok {
+				t.Fatal("expected the child request to have a deadline")
+			}
+			// Allow for the time spent between start and the deadlines being set.
+			if got := deadline.Sub(start); got > tc.expected+time.Second || got < tc.expected-time.Second {
+				t.Errorf("unexpected deadline, want: ~%v, got: %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestWithRequestDeadlineCancel(t *testing.T) {
+	parentCtx, cancelParent := context.WithCancel(context.Background())
+	defer cancelParent()
+	req := httptest.NewRequest("GET", "/", nil).WithContext(parentCtx)
+
+	child, cancel := WithRequestDeadline(req, time.Hour)
+	if err := child.Context().Err(); err != nil {
+		t.Fatalf("unexpected error before cancel: %v", err)
+	}
+	cancel()
+	if err := child.Context().Err(); err != context.Canceled {
+		t.Errorf("unexpected error, want: %v, got: %v", context.Canceled, err)
+	}
+	if err := req.Context().Err(); err != nil {
+		t.Errorf("cancelling the child must not cancel the parent: %v", err)
+	}
+
+	// Cancellation of the parent propagates to the child.
+	child, cancel = WithRequestDeadline(req, time.Hour)
+	defer cancel()
+	cancelParent()
+	select {
+	case <-child.Context().Done():
+	case <-time.After(time.Second):
+		t.Error("expected the child to be cancelled with its parent")
+	}
+}
@@ -0,0 +1,84 @@
+package otils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrTokenInvalid is returned by VerifyExpiringToken for tokens that
+	// are malformed or whose signature doesn't match, e.g. because they
+	// were tampered with or signed with another secret.
+	ErrTokenInvalid = errors.New("invalid token")
+	// ErrTokenExpired is returned by VerifyExpiringToken for
+	// authentic tokens whose expiry has passed.
+	ErrTokenExpired = errors.New("token expired")
+)
+
+// SignExpiringToken returns an opaque, URL safe token that carries payload
+// and expires after ttl, e.g. for short-lived download links. The token is
+// signed with HMAC-SHA256 using secret but not encrypted, so payload must not
+// hold anything that its bearer may not read.
+func SignExpiringToken(payload url.Values, secret []byte, ttl time.Duration) string {
+	return signExpiringToken(payload, secret, time.Now().Add(ttl))
+}
+
+// VerifyExpiringToken checks the signature and expiry of a token made by
+// SignExpiringToken with the same secret and returns its payload. It returns
+// ErrTokenInvalid if the token isn't authentic and ErrTokenExpired if it is
+// but has expired.
+func VerifyExpiringToken(token string, secret []byte) (url.Values, error) {
+	return verifyExpiringToken(token, secret, time.Now())
+}
+
+// The signed body of a token is "<expiry>.<payload>", the expiry in Unix
+// seconds and the payload in its query string form, followed by its MAC.
+func signExpiringToken(payload url.Values, secret []byte, expiry time.Time) string {
+	body := []byte(strconv.FormatInt(expiry.Unix(), 10) + "." + payload.Encode())
+	return base64.RawURLEncoding.EncodeToString(body) + "." +
+		base64.RawURLEncoding.EncodeToString(tokenMAC(body, secret))
+}
+
+func verifyExpiringToken(token string, secret []byte, now time.Time) (url.Values, error) {
+	i := strings.LastIndexByte(token, '.')
+	if i < 0 {
+		return nil, ErrTokenInvalid
+	}
+	body, err := base64.RawURLEncoding.DecodeString(token[:i])
+	if err != nil {
+		return nil, ErrTokenInvalid
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(token[i+1:])
+	if err != nil || !hmac.Equal(mac, tokenMAC(body, secret)) {
+		return nil, ErrTokenInvalid
+	}
+
+	parts := strings.SplitN(string(body), ".", 2)
+	if len(parts) != 2 {
+		return nil, ErrTokenInvalid
+	}
+	expiry, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return nil, ErrTokenInvalid
+	}
+	if !now.Before(time.Unix(expiry, 0)) {
+		return nil, ErrTokenExpired
+	}
+	payload, err := url.ParseQuery(parts[1])
+	if err != nil {
+		return nil, ErrTokenInvalid
+	}
+	return payload, nil
+}
+
+func tokenMAC(body, secret []byte) []byte {
+	h := hmac.New(sha256.New, secret)
+	h.Write(body)
+	return h.Sum(nil)
+}
@@ -0,0 +1,78 @@
+package otils
+
+import (
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVerifyExpiringToken(t *testing.T) {
+	secret := []byte("s3cr3t")
+	payload := url.Values{"file": {"report.pdf"}, "user": {"42"}}
+	now := time.Date(2021, time.March, 4, 5, 6, 7, 0, time.UTC)
+	token := signExpiringToken(payload, secret, now.Add(time.Minute))
+
+	// Flip a character of the signed body, keeping it valid base64.
+	tampered := []byte(token)
+	if tampered[3] == 'A' {
+		tampered[3] = 'B'
+	} else {
+		tampered[3] = 'A'
+	}
+
+	tests := []struct {
+		name     string
+		token    string
+		secret   []byte
+		now      time.Time
+		expected url.Values
+		err      error
+	}{
+		{name: "valid", token: token, secret: secret, now: now, expected: payload},
+		{name: "just before expiry", token: token, secret: secret, now: now.Add(time.Minute - time.Second), expected: payload},
+		{name: "expired", token: token, secret: secret, now: now.Add(time.Minute), err: ErrTokenExpired},
+		{name: "tampered", token: string(tampered), secret: secret, now: now, err: ErrTokenInvalid},
+		{name: "wrong secret", token: token, secret: []byte("other"), now: now, err: ErrTokenInvalid},
+		{name: "truncated signature", token: token[:len(token)-2], secret: secret, now: now, err: ErrTokenInvalid},
+		{name: "malformed", token: "not-a-token", secret: secret, now: now, err: ErrTokenInvalid},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := verifyExpiringToken(tc.token, tc.secret, tc.now)
+			if err != tc.err {
+				t.Fatalf("unexpected error, want: %v, got: %v", tc.err, err)
+			}
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("unexpected payload, want: %v, got: %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestSignExpiringToken(t *testing.T) {
+	secret := []byte("s3cr3t")
+	payload := url.Values{"q": {"a b&c=d"}}
+	token := SignExpiringToken(payload, secret, time.Hour)
+	if escaped := url.QueryEscape(token); escaped != token {
+		t.Errorf("token isn't URL safe: %q", token)
+	}
+	if strings.Contains(token, "a b") {
+		t.Errorf("payload should be encoded in the token: %q", token)
+	}
+	got, err := VerifyExpiringToken(token, secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, payload) {
+		t.Errorf("unexpected payload, want: %v, got: %v", payload, got)
+	}
+
+	if _, err := VerifyExpiringToken(SignExpiringToken(payload, secret, -time.Second), secret); err != ErrTokenExpired {
+		t.Errorf("unexpected error, want: %v, got: %v", ErrTokenExpired, err)
+	}
+}
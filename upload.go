@@ -0,0 +1,88 @@
+package otils
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// UploadedFile is a file that was uploaded in a multipart form.
+type UploadedFile struct {
+	Filename    string
+	ContentType string
+	Size        int64
+	io.ReadCloser
+}
+
+// ParseUpload reads the multipart form body of r, returning its text fields
+// and its files keyed by their form names. If a name is repeated only its
+// first value is kept. Each file may be at most maxFile bytes and the whole
+// form, which is buffered in memory, at most maxMemory bytes. The returned
+// errors are *CodedError values whose Code is the status to respond with:
+// 415 for a body that isn't multipart, 413 for a file or form that exceeds
+// its limit and 400 for a malformed body.
+func ParseUpload(r *http.Request, maxMemory, maxFile int64) (fields map[string]string, files map[string]*UploadedFile, err error) {
+	mr, err := r.MultipartReader()
+	if err != nil {
+		if errors.Is(err, http.ErrNotMultipart) {
+			return nil, nil, MakeCodedError(err.Error(), http.StatusUnsupportedMediaType)
+		}
+		return nil, nil, MakeCodedError(err.Error(), http.StatusBadRequest)
+	}
+
+	fields = make(map[string]string)
+	files = make(map[string]*UploadedFile)
+	remaining := maxMemory
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, MakeCodedError("malformed multipart body: "+err.Error(), http.StatusBadRequest)
+		}
+
+		name, filename := part.FormName(), part.FileName()
+		limit := remaining
+		if filename != "" && maxFile < limit {
+			limit = maxFile
+		}
+		buf := new(bytes.Buffer)
+		n, err := CopyN(buf, part, limit)
+		_ = part.Close()
+		switch {
+		case err == ErrLimitExceeded && limit < remaining:
+			msg := fmt.Sprintf("file %q is larger than %d bytes", filename, maxFile)
+			return nil, nil, MakeCodedError(msg, http.StatusRequestEntityTooLarge)
+		case err == ErrLimitExceeded:
+			msg := fmt.Sprintf("form is larger than %d bytes", maxMemory)
+			return nil, nil, MakeCodedError(msg, http.StatusRequestEntityTooLarge)
+		case err != nil:
+			return nil, nil, MakeCodedError("malformed multipart body: "+err.Error(), http.StatusBadRequest)
+		}
+		remaining -= n
+
+		if filename == "" {
+			if _, ok := fields[name]; !ok {
+				fields[name] = buf.String()
+			}
+			continue
+		}
+		if _, ok := files[name]; ok {
+			continue
+		}
+		contentType := part.Header.Get("Content-Type")
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		files[name] = &UploadedFile{
+			Filename:    filename,
+			ContentType: contentType,
+			Size:        n,
+			ReadCloser:  io.NopCloser(bytes.NewReader(buf.Bytes())),
+		}
+	}
+	return fields, files, nil
+}
@@ -0,0 +1,122 @@
+package otils
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"strings"
+	"testing"
+)
+
+func newUploadRequest(t *testing.T, fields map[string]string, filename, content string) *http.Request {
+	t.Helper()
+	body := new(bytes.Buffer)
+	mw := multipart.NewWriter(body)
+	for name, value := range fields {
+		if err := mw.WriteField(name, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", `form-data; name="avatar"; filename="`+filename+`"`)
+	header.Set("Content-Type", "image/png")
+	fw, err := mw.CreatePart(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.WriteString(fw, content); err != nil {
+		t.Fatal(err)
+	}
+	if err := mw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest("POST", "/upload", body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+func TestParseUpload(t *testing.T) {
+	req := newUploadRequest(t, map[string]string{"title": "me"}, "me.png", "PNGDATA")
+	fields, files, err := ParseUpload(req, 1<<10, 100)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := fields["title"], "me"; got != want {
+		t.Errorf("unexpected field, want: %q, got: %q", want, got)
+	}
+	file := files["avatar"]
+	if file == nil {
+		t.Fatal("expected the avatar file")
+	}
+	defer file.Close()
+	if file.Filename != "me.png" || file.ContentType != "image/png" || file.Size != 7 {
+		t.Errorf("unexpected file: %+v", file)
+	}
+	content, err := io.ReadAll(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(content), "PNGDATA"; got != want {
+		t.Errorf("unexpected content, want: %q, got: %q", want, got)
+	}
+}
+
+func TestParseUploadErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		req       func(t *testing.T) *http.Request
+		maxMemory int64
+		maxFile   int64
+		wantCode  int
+	}{
+		{
+			name: "file too large",
+			req: func(t *testing.T) *http.Request {
+				return newUploadRequest(t, nil, "big.png", strings.Repeat("x", 101))
+			},
+			maxMemory: 1 << 10,
+			maxFile:   100,
+			wantCode:  http.StatusRequestEntityTooLarge,
+		},
+		{
+			name: "form too large",
+			req: func(t *testing.T) *http.Request {
+				return newUploadRequest(t, map[string]string{"notes": strings.Repeat("n", 60)}, "a.png", strings.Repeat("x", 50))
+			},
+			maxMemory: 100,
+			maxFile:   100,
+			wantCode:  http.StatusRequestEntityTooLarge,
+		},
+		{
+			name: "not multipart",
+			req: func(t *testing.T) *http.Request {
+				req := httptest.NewRequest("POST", "/upload", strings.NewReader("title=me"))
+				req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+				return req
+			},
+			maxMemory: 1 << 10,
+			maxFile:   100,
+			wantCode:  http.StatusUnsupportedMediaType,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			_, _, err := ParseUpload(tc.req(t), tc.maxMemory, tc.maxFile)
+			var cerr *CodedError
+			if !errors.As(err, &cerr) {
+				t.Fatalf("expected a *CodedError, got: %v", err)
+			}
+			if cerr.Code() != tc.wantCode {
+				t.Errorf("unexpected code, want: %d, got: %d (%v)", tc.wantCode, cerr.Code(), cerr)
+			}
+		})
+	}
+}
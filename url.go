@@ -1,11 +1,29 @@
 package otils
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"database/sql"
+	"database/sql/driver"
+	"encoding"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
+	"net"
+	"net/mail"
 	"net/url"
 	"reflect"
+	"runtime"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
 )
 
 // ToURLValues transforms any type with fields into a url.Values map
@@ -30,175 +48,1140 @@ import (
 // Into:
 // "logo.dimension.extra.shade=48%25&logo.dimension.extra.zoom=false&logo.dimension.height=120&logo.dimension.width=100&logo.url=https%3A%2F%2Forijtech.com%2Ffavicon.ico"
 func ToURLValues(v interface{}) (url.Values, error) {
-	val := reflect.ValueOf(v)
+	return ToURLValuesWithOptions(v, nil)
+}
+
+// ToURLValuesWith is like ToURLValues except that each key in overrides
+// is then set to its values, replacing any that v produced for it.
+func ToURLValuesWith(v interface{}, overrides url.Values) (url.Values, error) {
+	values, err := ToURLValues(v)
+	if err != nil {
+		return nil, err
+	}
+	if values == nil {
+		values = make(url.Values)
+	}
+	for key, vs := range overrides {
+		values[key] = append([]string(nil), vs...)
+	}
+	return values, nil
+}
+
+// ToURLValuesWithChecksum is like ToURLValues except that it adds the
+// SHA-256 checksum, in hex, of the encoded values under checksumKey so that
+// receivers can detect accidental corruption with VerifyURLValuesChecksum. The
+// checksum isn't keyed so it doesn't protect against deliberate tampering;
+// SignExpiringToken does. It is an error for v to produce checksumKey itself.
+func ToURLValuesWithChecksum(v interface{}, checksumKey string) (url.Values, error) {
+	values, err := ToURLValues(v)
+	if err != nil {
+		return nil, err
+	}
+	if values == nil {
+		values = make(url.Values)
+	}
+	if _, ok := values[checksumKey]; ok {
+		return nil, fmt.Errorf("checksum key %q is already set", checksumKey)
+	}
+	values.Set(checksumKey, urlValuesChecksum(values))
+	return values, nil
+}
+
+// VerifyURLValuesChecksum reports whether values hold a single value under
+// checksumKey that matches the checksum of the other values, as added by
+// ToURLValuesWithChecksum. The order of keys and values in the query
+// string doesn't matter, only the order of each key's repeated values does.
+func VerifyURLValuesChecksum(values url.Values, checksumKey string) bool {
+	sums := values[checksumKey]
+	if len(sums) != 1 {
+		return false
+	}
+	rest := make(url.Values, len(values))
+	for key, vs := range values {
+		if key != checksumKey {
+			rest[key] = vs
+		}
+	}
+	return sums[0] == urlValuesChecksum(rest)
+}
+
+// urlValuesChecksum returns the hex SHA-256 of the canonical,
+// key sorted encoding of values.
+func urlValuesChecksum(values url.Values) string {
+	sum := sha256.Sum256([]byte(values.Encode()))
+	return hex.EncodeToString(sum[:])
+}
+
+// WithQuery returns rawURL with each key in query set on its query
+// string, replacing any values that rawURL already had for that key.
+// Keys of rawURL's query that aren't in query are left as they were.
+func WithQuery(rawURL string, query url.Values) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	merged := u.Query()
+	for key, values := range query {
+		merged[key] = values
+	}
+	u.RawQuery = merged.Encode()
+	return u.String(), nil
+}
+
+// JoinURLPath returns base with each of segments appended to its path,
+// separated by exactly one slash regardless of any leading or trailing
+// slashes on base or the segments. Each segment is percent-encoded as a
+// single path segment, so a slash inside of a segment is escaped rather
+// than starting a new one. The query and fragment of base are preserved.
+func JoinURLPath(base string, segments ...string) (string, error) {
+	u, err := url.Parse(base)
+	if err != nil {
+		return "", err
+	}
+	path := strings.TrimSuffix(u.Path, "/")
+	rawPath := strings.TrimSuffix(u.EscapedPath(), "/")
+	for _, segment := range segments {
+		segment = strings.Trim(segment, "/")
+		if segment == "" {
+			continue
+		}
+		path += "/" + segment
+		rawPath += "/" + url.PathEscape(segment)
+	}
+	u.Path, u.RawPath = path, rawPath
+	return u.String(), nil
+}
+
+// ValidateHTTPURL parses raw and returns it if it is an absolute http or
+// https URL with a host, for use before making outbound requests to it.
+// URLs that carry user info, which could leak credentials or disguise
+// the real host, and those that contain control characters are rejected.
+func ValidateHTTPURL(raw string) (*url.URL, error) {
+	for _, r := range raw {
+		if unicode.IsControl(r) {
+			return nil, fmt.Errorf("url %q contains control characters", raw)
+		}
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https":
+	case "":
+		return nil, fmt.Errorf("url %q has no scheme, expecting http or https", raw)
+	default:
+		return nil, fmt.Errorf("url %q has scheme %q, expecting http or https", raw, u.Scheme)
+	}
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("url %q has no host", raw)
+	}
+	if u.User != nil {
+		return nil, fmt.Errorf("url %q must not contain user info", u.Redacted())
+	}
+	return u, nil
+}
+
+// URLValuesOptions controls how ToURLValuesWithOptions
+// transforms values into url.Values.
+type URLValuesOptions struct {
+	// KeepZeroNumbers when set emits numeric fields whose value is zero
+	// unless they are tagged with omitempty. When unset, zero numbers are
+	// left out like other zero values, which is what ToURLValues does.
+	KeepZeroNumbers bool
+
+	// EnumNames maps integer enum types to the names that their
+	// values should be emitted as instead of their numeric values.
+	// Values that aren't registered are emitted as numbers.
+	EnumNames map[reflect.Type]map[int64]string
+
+	// ASCIIKeysOnly when set makes ToURLValuesWithOptions return an
+	// error if any produced key contains non-ASCII characters, which
+	// typically come from user controlled map keys.
+	ASCIIKeysOnly bool
+
+	// Prefix when set is prepended as "<Prefix>." to every top-level
+	// key, namespacing the output for merging into a larger query.
+	// With KeyStyleBracketed, keys become "<Prefix>[key]" instead.
+	Prefix string
+
+	// EmitEmptySlices when set emits a single empty value for slice
+	// fields that have no elements, e.g. "ids=", which some APIs take
+	// to mean "clear all". Fields tagged with omitempty are still left out.
+	EmitEmptySlices bool
+
+	// NilSlices and EmptySlices override EmitEmptySlices for slice fields
+	// that are nil and for those that are non-nil but have no elements,
+	// respectively. This distinguishes a field that was never set from one
+	// that was explicitly cleared, e.g. by omitting nil slices while emitting
+	// "ids=" for empty ones. Fields tagged with omitempty are still left out.
+	NilSlices   EmptySliceFormat
+	EmptySlices EmptySliceFormat
+
+	// BoolFormat controls how booleans are emitted. Note that false
+	// fields and map entries are left out as blank like empty strings
+	// are, so the format of false applies to slice elements, fields
+	// set through non-nil pointers and sql.NullBool values.
+	BoolFormat BoolFormat
+
+	// CollapseSingleElementSlices when set encodes slice fields and map
+	// entries that hold exactly one element as if they held that element
+	// alone, for backends that reject repeated or indexed keys. For example
+	// a slice with a single struct emits "items.name=x" instead of
+	// "items.0.name=x". Slices with more elements are encoded as usual.
+	CollapseSingleElementSlices bool
+
+	// KeyFilter when set is called with every produced key, including
+	// any Prefix, and the entries for which it returns false are dropped.
+	KeyFilter func(key string) bool
+
+	// TimeLayout is the layout that time.Time and sql.NullTime values
+	// are formatted with, defaulting to time.RFC3339. The TimeLayoutUnix
+	// and TimeLayoutUnixMilli sentinels emit Unix timestamps instead.
+	// Zero times and invalid sql.NullTime values are left out as blank.
+	TimeLayout string
+
+	// TimeInUTC when set converts time.Time and sql.NullTime values to
+	// UTC before formatting them, instead of keeping their own location.
+	TimeInUTC bool
+
+	// UnsortedMapKeys when set emits map entries in Go's nondeterministic
+	// map iteration order instead of sorted by key, saving the sort. This
+	// only makes a difference to ordered results and to the order of the
+	// values of repeated keys, so it is only of use if whatever consumes
+	// the output sorts it anyway.
+	UnsortedMapKeys bool
+
+	// SpaceAsPercent20 when set encodes spaces in values as "%20"
+	// instead of "+" for servers that don't decode the latter. It
+	// applies to the query strings that ToQueryString returns.
+	SpaceAsPercent20 bool
+
+	// KeyStyle is how the keys of nested values are joined to the
+	// keys of their parents, defaulting to KeyStyleDotted.
+	KeyStyle KeyStyle
+
+	// SliceIndexBase is added to the index of slice elements in the
+	// keys that they're emitted under, at any depth, e.g. 1 for APIs
+	// that expect "items.1" and "items.2" for the first two elements.
+	SliceIndexBase int
+
+	// IsBlankFunc when set is consulted in addition to the built-in
+	// checks to decide whether the scalar value of a field or map entry
+	// is blank and left out, e.g. to treat "N/A" or "null" as blank. It
+	// isn't consulted for slice elements which are never left out.
+	IsBlankFunc func(v interface{}) bool
+
+	// MaxKeys when positive caps the number of key and value pairs,
+	// counting each value of a repeated key, that may be produced.
+	// Exceeding it is an error, which guards upstream servers against
+	// oversized query strings. Zero means unlimited.
+	MaxKeys int
+
+	// OnKeyConflict is what happens when a flattened field, such as an
+	// embedded struct or a map tagged "inline", emits a key that another
+	// field of the same struct also emits. It defaults to KeyConflictAppend.
+	OnKeyConflict KeyConflictMode
 
+	// FieldOrder when set reorders the pairs of ToOrderedURLValuesWithOptions
+	// so that the keys named in it come first, in its order, followed by all
+	// other keys sorted, e.g. for signature schemes that mandate an order.
+	// A name also matches the keys nested under it, so "filter" matches
+	// "filter.name", and those keys are sorted too. It has no effect on
+	// url.Values which are unordered.
+	FieldOrder []string
+
+	// NonFiniteFloats is what happens to NaN and infinite float values,
+	// which many servers reject. It defaults to NonFiniteFloatError.
+	NonFiniteFloats NonFiniteFloatPolicy
+
+	// ByteSliceEncoding is how []byte and [N]byte values are
+	// emitted, defaulting to ByteSliceBase64.
+	ByteSliceEncoding ByteSliceEncoding
+}
+
+const (
+	// TimeLayoutUnix is a TimeLayout that emits
+	// times as seconds since the Unix epoch.
+	TimeLayoutUnix = "unix"
+	// TimeLayoutUnixMilli is a TimeLayout that emits
+	// times as milliseconds since the Unix epoch.
+	TimeLayoutUnixMilli = "unixmilli"
+)
+
+func (opts *URLValuesOptions) formatTime(t time.Time) string {
+	if opts.TimeInUTC {
+		t = t.UTC()
+	}
+	switch opts.TimeLayout {
+	case "":
+		return t.Format(time.RFC3339)
+	case TimeLayoutUnix:
+		return strconv.FormatInt(t.Unix(), 10)
+	case TimeLayoutUnixMilli:
+		return strconv.FormatInt(t.UnixMilli(), 10)
+	default:
+		return t.Format(opts.TimeLayout)
+	}
+}
+
+// KeyStyle is how the keys of nested values are formed.
+type KeyStyle int
+
+const (
+	// KeyStyleDotted joins keys with dots e.g. "filter.name".
+	KeyStyleDotted KeyStyle = iota
+	// KeyStyleBracketed nests keys in brackets e.g. "filter[name]",
+	// as understood by frameworks such as Rails and PHP.
+	KeyStyleBracketed
+)
+
+// NonFiniteFloatPolicy is how NaN and infinite floats are handled.
+type NonFiniteFloatPolicy int
+
+const (
+	// NonFiniteFloatError fails the encoding, to surface bad data.
+	NonFiniteFloatError NonFiniteFloatPolicy = iota
+	// NonFiniteFloatSkip leaves the values out.
+	NonFiniteFloatSkip
+	// NonFiniteFloatAsString emits "NaN", "+Inf" or "-Inf".
+	NonFiniteFloatAsString
+)
+
+// ByteSliceEncoding is how byte slices and arrays are emitted.
+type ByteSliceEncoding int
+
+const (
+	// ByteSliceBase64 emits bytes in standard, padded base64.
+	ByteSliceBase64 ByteSliceEncoding = iota
+	// ByteSliceHex emits bytes in lowercase hexadecimal.
+	ByteSliceHex
+)
+
+func (e ByteSliceEncoding) format(b []byte) string {
+	if e == ByteSliceHex {
+		return hex.EncodeToString(b)
+	}
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+// KeyConflictMode is how keys emitted by more than one field are handled.
+type KeyConflictMode int
+
+const (
+	// KeyConflictAppend keeps the values of all the fields under the key.
+	KeyConflictAppend KeyConflictMode = iota
+	// KeyConflictError fails with an error that names the key.
+	KeyConflictError
+)
+
+// EmptySliceFormat is how slice fields without elements are emitted.
+type EmptySliceFormat int
+
+const (
+	// EmptySliceDefault follows EmitEmptySlices.
+	EmptySliceDefault EmptySliceFormat = iota
+	// EmptySliceOmit leaves the field out.
+	EmptySliceOmit
+	// EmptySliceEmit emits a single empty value for the field.
+	EmptySliceEmit
+)
+
+// emitEmptySlice reports whether the slice or array v, which has
+// no elements, should be emitted as a single empty value.
+func (opts *URLValuesOptions) emitEmptySlice(v reflect.Value) bool {
+	format := opts.EmptySlices
+	if v.Kind() == reflect.Slice && v.IsNil() {
+		format = opts.NilSlices
+	}
+	switch format {
+	case EmptySliceOmit:
+		return false
+	case EmptySliceEmit:
+		return true
+	default:
+		return opts.EmitEmptySlices
+	}
+}
+
+// BoolFormat is the format that booleans are emitted in.
+type BoolFormat int
+
+const (
+	// BoolTrueFalse emits "true" and "false".
+	BoolTrueFalse BoolFormat = iota
+	// BoolOneZero emits "1" and "0".
+	BoolOneZero
+	// BoolYesNo emits "yes" and "no".
+	BoolYesNo
+)
+
+func (bf BoolFormat) format(b bool) string {
+	switch bf {
+	case BoolOneZero:
+		if b {
+			return "1"
+		}
+		return "0"
+	case BoolYesNo:
+		if b {
+			return "yes"
+		}
+		return "no"
+	default:
+		return strconv.FormatBool(b)
+	}
+}
+
+// ToURLValuesWithOptions is like ToURLValues except that it allows
+// the caller to control how values are transformed. Passing in nil
+// options is equivalent to invoking ToURLValues.
+func ToURLValuesWithOptions(v interface{}, opts *URLValuesOptions) (url.Values, error) {
+	pairs, err := toURLValuePairs(v, opts)
+	if err != nil || pairs == nil {
+		return nil, err
+	}
+	values := make(url.Values)
+	for _, pair := range pairs {
+		values.Add(pair.Key, pair.Value)
+	}
+	return values, nil
+}
+
+// ToQueryString is like ToURLValuesWithOptions except that it returns
+// the encoded query string, honoring the SpaceAsPercent20 option.
+func ToQueryString(v interface{}, opts *URLValuesOptions) (string, error) {
+	values, err := ToURLValuesWithOptions(v, opts)
+	if err != nil {
+		return "", err
+	}
+	return EncodeURLValuesWith(values, opts != nil && opts.SpaceAsPercent20), nil
+}
+
+// EncodeURLValuesWith is like v.Encode except that if spaceAsPercent
+// is set, spaces in values are encoded as "%20" instead of "+".
+func EncodeURLValuesWith(v url.Values, spaceAsPercent bool) string {
+	if !spaceAsPercent {
+		return v.Encode()
+	}
+	keys := make([]string, 0, len(v))
+	for key := range v {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	for _, key := range keys {
+		escapedKey := url.QueryEscape(key)
+		for _, value := range v[key] {
+			if sb.Len() > 0 {
+				sb.WriteByte('&')
+			}
+			// QueryEscape encodes a literal "+" as "%2B" so any
+			// "+" that it produces stands for a space.
+			sb.WriteString(escapedKey)
+			sb.WriteByte('=')
+			sb.WriteString(strings.ReplaceAll(url.QueryEscape(value), "+", "%20"))
+		}
+	}
+	return sb.String()
+}
+
+// KeyValue is a single key and value pair of a query string.
+type KeyValue struct {
+	Key, Value string
+}
+
+// ToOrderedURLValues is like ToURLValues except that it returns the key and
+// value pairs in the order that they are encountered: struct fields in their
+// declaration order, map entries sorted by key and slice elements by index.
+// Repeated keys are preserved as separate pairs. This is useful for schemes,
+// such as some legacy request signing ones, that depend on parameter order.
+func ToOrderedURLValues(v interface{}) ([]KeyValue, error) {
+	return toURLValuePairs(v, nil)
+}
+
+// URLValueKeys returns the sorted, distinct keys that ToURLValues would
+// emit for v, without their values, e.g. to log or audit which fields of
+// v would be exposed in a URL.
+func URLValueKeys(v interface{}) ([]string, error) {
+	pairs, err := toURLValuePairs(v, nil)
+	if err != nil || pairs == nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(pairs))
+	seen := make(map[string]bool, len(pairs))
+	for _, pair := range pairs {
+		if !seen[pair.Key] {
+			seen[pair.Key] = true
+			keys = append(keys, pair.Key)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// ToOrderedURLValuesWithOptions is like ToOrderedURLValues except that
+// it accepts options, including FieldOrder which only affects ordered
+// results. A nil opts is the same as ToOrderedURLValues.
+func ToOrderedURLValuesWithOptions(v interface{}, opts *URLValuesOptions) ([]KeyValue, error) {
+	return toURLValuePairs(v, opts)
+}
+
+func toURLValuePairs(v interface{}, opts *URLValuesOptions) ([]KeyValue, error) {
+	return encodeURLValuePairs(v, opts, runtime.GOMAXPROCS(0))
+}
+
+// encodeURLValuePairs is toURLValuePairs with up to workers goroutines
+// encoding the entries of large maps, so that tests can compare the
+// concurrent path against the serial one.
+func encodeURLValuePairs(v interface{}, opts *URLValuesOptions, workers int) ([]KeyValue, error) {
+	if opts == nil {
+		opts = &URLValuesOptions{}
+	}
+
+	val := reflect.ValueOf(v)
+	if val.Kind() == reflect.Ptr {
+		if val.IsNil() {
+			return nil, errInvalidValue
+		}
+		val = val.Elem()
+	}
 	switch val.Kind() {
-	case reflect.Ptr:
-		val = reflect.Indirect(val)
-	case reflect.Struct:
-		// Let this pass through
-	case reflect.Array, reflect.Slice:
-		return toURLValuesForSlice(v)
-	case reflect.Map:
-		return toURLValuesForMap(v)
+	case reflect.Struct, reflect.Map, reflect.Array, reflect.Slice:
+		// Let these pass through
 	default:
 		return nil, nil
 	}
 
-	fullMap := make(url.Values)
-	if !val.IsValid() {
-		return nil, errInvalidValue
+	enc := &urlEncoder{opts: opts, pairs: []KeyValue{}, workers: workers}
+	if err := enc.encode(opts.Prefix, val, fromField, nil); err != nil {
+		return nil, err
+	}
+	if opts.KeyFilter != nil {
+		kept := enc.pairs[:0]
+		for _, pair := range enc.pairs {
+			if opts.KeyFilter(pair.Key) {
+				kept = append(kept, pair)
+			}
+		}
+		enc.pairs = kept
+	}
+	if opts.MaxKeys > 0 && len(enc.pairs) > opts.MaxKeys {
+		return nil, fmt.Errorf("produced %d query parameters, exceeding MaxKeys of %d", len(enc.pairs), opts.MaxKeys)
 	}
+	if opts.ASCIIKeysOnly {
+		for _, pair := range enc.pairs {
+			if !isASCII(pair.Key) {
+				return nil, fmt.Errorf("key %q contains non-ASCII characters", pair.Key)
+			}
+		}
+	}
+	if len(opts.FieldOrder) > 0 {
+		orderPairs(enc.pairs, opts.FieldOrder)
+	}
+	return enc.pairs, nil
+}
 
-	typ := val.Type()
-	nfields := val.NumField()
+// orderPairs sorts pairs so that those under the keys in order come first,
+// in that order, followed by the others. Pairs under the same key in order,
+// and the others, are sorted by key, regardless of the map iteration order
+// that UnsortedMapKeys leaves in. The pairs of a key keep their relative order.
+func orderPairs(pairs []KeyValue, order []string) {
+	rank := func(key string) int {
+		for i, name := range order {
+			if key == name || strings.HasPrefix(key, name+".") || strings.HasPrefix(key, name+"[") {
+				return i
+			}
+		}
+		return len(order)
+	}
+	sort.SliceStable(pairs, func(i, j int) bool {
+		ri, rj := rank(pairs[i].Key), rank(pairs[j].Key)
+		if ri != rj {
+			return ri < rj
+		}
+		return pairs[i].Key < pairs[j].Key
+	})
+}
 
-	for i := 0; i < nfields; i++ {
-		fieldVal := val.Field(i)
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
 
-		// Dereference that pointer
-		if fieldVal.Kind() == reflect.Ptr {
-			fieldVal = reflect.Indirect(fieldVal)
+// valueSource describes where a value being encoded was found
+// which determines when it is considered blank and left out.
+type valueSource int
+
+const (
+	// fromField values are left out when blank or zero,
+	// according to their tag options and KeepZeroNumbers.
+	fromField valueSource = iota
+	// fromMapEntry values are only left out when blank,
+	// since the presence of the entry is deliberate.
+	fromMapEntry
+	// fromSliceElem values are never left out since
+	// that would change the meaning of the slice.
+	fromSliceElem
+	// fromPointer values are those of fields reached through a
+	// non-nil pointer and like encoding/json, they are never left
+	// out since setting the pointer marks them as present.
+	fromPointer
+)
+
+// urlEncoder walks values and accumulates the
+// key and value pairs that they are encoded into.
+type urlEncoder struct {
+	opts  *URLValuesOptions
+	pairs []KeyValue
+
+	// workers bounds the goroutines used to encode the entries of
+	// a large map. Nested maps are always encoded serially.
+	workers int
+}
+
+func (enc *urlEncoder) add(key, value string) {
+	enc.pairs = append(enc.pairs, KeyValue{Key: key, Value: value})
+}
+
+// isBlankFunc reports whether the IsBlankFunc option considers v blank.
+func (enc *urlEncoder) isBlankFunc(v reflect.Value, src valueSource) bool {
+	return enc.opts.IsBlankFunc != nil && src != fromSliceElem && enc.opts.IsBlankFunc(v.Interface())
+}
+
+// index returns the name of the slice element at index i as used in keys.
+func (enc *urlEncoder) index(i int) string {
+	return strconv.Itoa(i + enc.opts.SliceIndexBase)
+}
+
+// topLevel reports whether key is that of the value being encoded itself,
+// which is empty or the Prefix option, rather than that of a nested one.
+func (enc *urlEncoder) topLevel(key string) bool {
+	return key == enc.opts.Prefix
+}
+
+// joinKey returns the key of the child named key of prefix.
+func (enc *urlEncoder) joinKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	if enc.opts.KeyStyle == KeyStyleBracketed {
+		return prefix + "[" + key + "]"
+	}
+	return prefix + "." + key
+}
+
+func (enc *urlEncoder) encode(key string, v reflect.Value, src valueSource, tagOpts tagOptions) error {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		if v.Kind() == reflect.Ptr && src == fromField {
+			src = fromPointer
 		}
+		return enc.encode(key, v.Elem(), src, tagOpts)
+	}
 
-		if fieldVal.Kind() == reflect.Invalid {
-			continue
+	switch v.Kind() {
+	case reflect.Chan, reflect.Func, reflect.UnsafePointer:
+		// These have no meaningful query string form,
+		// only their addresses, so they're skipped.
+		return nil
+	}
+
+	// Top-level and embedded values have no key of their own,
+	// so they only produce pairs for their fields and elements.
+	keyless := key == "" && (src == fromField || src == fromPointer)
+
+	if inner, ok := sqlNullValue(v); ok {
+		if !inner.IsValid() || keyless || enc.isBlankFunc(v, src) {
+			return nil
+		}
+		if skip, err := enc.opts.checkFinite(key, inner); skip || err != nil {
+			return err
+		}
+		if value := enc.opts.formatValue(inner); value != "" {
+			enc.add(key, value)
+		}
+		return nil
+	}
+	if value, ok := enc.scalarString(v); ok {
+		if value != "" && !keyless && !enc.isBlankFunc(v, src) {
+			enc.add(key, value)
+		}
+		return nil
+	}
+	if m, ok := textMarshalerOf(v); ok {
+		if keyless {
+			return nil
+		}
+		text, err := m.MarshalText()
+		if err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		if len(text) > 0 && !enc.isBlankFunc(v, src) {
+			enc.add(key, string(text))
+		}
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		return enc.encodeStruct(key, v)
+
+	case reflect.Map:
+		return enc.encodeMap(key, v)
+
+	case reflect.Array, reflect.Slice:
+		if v.Len() == 0 && (src == fromField || src == fromPointer) && !enc.topLevel(key) {
+			if enc.opts.emitEmptySlice(v) && !tagOpts.has("omitempty") {
+				enc.add(key, "")
+			}
+			return nil
+		}
+		if v.Type().Elem().Kind() != reflect.Uint8 {
+			// Arrays always have elements, so zero ones are left
+			// out like other zero fields before they're examined.
+			if v.Kind() == reflect.Array && src == fromField && enc.opts.isBlankValue(v, tagOpts.has("omitempty")) {
+				return nil
+			}
+			if enc.opts.CollapseSingleElementSlices && v.Len() == 1 && !enc.topLevel(key) && src != fromSliceElem {
+				return enc.encode(key, v.Index(0), fromSliceElem, nil)
+			}
+			return enc.encodeSlice(key, v)
+		}
+	}
+	if keyless {
+		return nil
+	}
+
+	switch src {
+	case fromField:
+		if enc.opts.isBlankValue(v, tagOpts.has("omitempty")) {
+			return nil
 		}
+	case fromMapEntry:
+		if isBlank(v.Interface()) || isBlankReflectValue(v) {
+			return nil
+		}
+	}
+	if enc.isBlankFunc(v, src) {
+		return nil
+	}
+	if skip, err := enc.opts.checkFinite(key, v); skip || err != nil {
+		return err
+	}
+	enc.add(key, enc.opts.formatValue(v))
+	return nil
+}
 
+func (enc *urlEncoder) encodeStruct(prefix string, v reflect.Value) error {
+	// owners maps the keys emitted so far at this level to the fields
+	// that emitted them, to detect the conflicts of flattened fields.
+	var owners map[string]keyOwner
+	if enc.opts.OnKeyConflict == KeyConflictError {
+		owners = make(map[string]keyOwner)
+	}
+
+	typ := v.Type()
+	for i := 0; i < v.NumField(); i++ {
 		fieldTyp := typ.Field(i)
-		if unexportedField(fieldTyp.Name) {
+		start := len(enc.pairs)
+		flattened, err := enc.encodeField(prefix, v.Field(i), fieldTyp)
+		if err != nil {
+			return err
+		}
+		if owners == nil {
 			continue
 		}
+		owner := keyOwner{field: fieldTyp.Name, flattened: flattened}
+		for _, pair := range enc.pairs[start:] {
+			prev, ok := owners[pair.Key]
+			if ok && prev.field != owner.field && (prev.flattened || owner.flattened) {
+				return fmt.Errorf("key %q of field %s conflicts with field %s", pair.Key, owner.field, prev.field)
+			}
+			if !ok {
+				owners[pair.Key] = owner
+			}
+		}
+	}
+	return nil
+}
 
-		parentTag, omitempty, ignore := jsonTag(fieldTyp)
-		if ignore {
-			continue
+// keyOwner is the struct field that a key was emitted for.
+type keyOwner struct {
+	field     string
+	flattened bool
+}
+
+// encodeField encodes the field of a struct whose keys are under prefix,
+// reporting whether the field was flattened into that level.
+func (enc *urlEncoder) encodeField(prefix string, fieldVal reflect.Value, fieldTyp reflect.StructField) (flattened bool, err error) {
+	if unexportedField(fieldTyp) {
+		// Like encoding/json, the exported fields of an embedded
+		// struct are promoted even if its type is unexported.
+		if !promotedUnexported(fieldTyp, fieldVal) {
+			return false, nil
+		}
+		if fieldVal.Kind() == reflect.Ptr {
+			if fieldVal.IsNil() {
+				return false, nil
+			}
+			fieldVal = fieldVal.Elem()
 		}
+		return true, enc.encodeStruct(prefix, fieldVal)
+	}
 
-		switch fieldVal.Kind() {
-		case reflect.Map:
-			keys := fieldVal.MapKeys()
-
-			for _, key := range keys {
-				value := fieldVal.MapIndex(key)
-				vIface := value.Interface()
-				innerValueMap, err := ToURLValues(vIface)
-				if err == nil && innerValueMap == nil {
-					zeroValue := reflect.Zero(value.Type())
-					blank := isBlank(vIface) || isBlankReflectValue(value) || reflect.DeepEqual(zeroValue.Interface(), vIface)
-
-					if omitempty && blank {
-						continue
-					}
-					if !blank {
-						keyname := strings.Join([]string{parentTag, fmt.Sprintf("%v", key)}, ".")
-						fullMap.Add(keyname, fmt.Sprintf("%v", vIface))
-					}
-					continue
-				}
+	tag, tagOpts, ignore := fieldTag(fieldTyp)
+	if ignore {
+		return false, nil
+	}
+	key := enc.joinKey(prefix, tag)
 
-				for key, innerValueList := range innerValueMap {
-					keyname := strings.Join([]string{parentTag, key}, ".")
-					fullMap[keyname] = append(fullMap[keyname], innerValueList...)
-				}
+	if isRequiredField(fieldTyp, tagOpts) && isEmptyFieldValue(fieldVal) {
+		return false, fmt.Errorf("required field %s (%q) is blank", fieldTyp.Name, key)
+	}
+
+	if fieldVal.Kind() == reflect.Ptr && fieldVal.IsNil() {
+		if tagOpts.has("emitnil") {
+			enc.add(key, "")
+		}
+		return false, nil
+	}
+
+	if tagOpts.has("json") {
+		return false, enc.addJSON(key, fieldVal)
+	}
+
+	if flattenEmbedded(fieldTyp, fieldVal) || flattenTagged(fieldTyp, tagOpts) {
+		// Promote the embedded fields or entries to this level.
+		key, flattened = prefix, true
+	}
+	return flattened, enc.encode(key, fieldVal, fromField, tagOpts)
+}
+
+func (enc *urlEncoder) encodeMap(prefix string, v reflect.Value) error {
+	if enc.isBasicMap(v.Type()) {
+		return enc.encodeBasicMap(prefix, v)
+	}
+	return enc.encodeMapEntries(prefix, v)
+}
+
+// concurrentMapMinEntries is the number of entries from which a map is
+// worth encoding concurrently; below it the goroutines cost more than they save.
+const concurrentMapMinEntries = 256
+
+type mapEntry struct {
+	key   string
+	value reflect.Value
+}
+
+// encodeMapEntries encodes any map by formatting its keys with
+// mapKeyString and encoding its values like any other value.
+func (enc *urlEncoder) encodeMapEntries(prefix string, v reflect.Value) error {
+	entries := make([]mapEntry, 0, v.Len())
+	iter := v.MapRange()
+	for iter.Next() {
+		key, err := mapKeyString(iter.Key())
+		if err != nil {
+			return err
+		}
+		entries = append(entries, mapEntry{key: key, value: iter.Value()})
+	}
+	if !enc.opts.UnsortedMapKeys {
+		sort.Slice(entries, func(i, j int) bool {
+			return entries[i].key < entries[j].key
+		})
+	}
+
+	// User code, such as IsBlankFunc or the methods that encoding a value
+	// may call, isn't required to be safe for concurrent use, so only maps
+	// whose values can't reach any of it are encoded concurrently.
+	if enc.workers > 1 && len(entries) >= concurrentMapMinEntries &&
+		enc.opts.IsBlankFunc == nil && methodFree(v.Type().Elem(), nil) {
+		return enc.encodeMapEntriesConcurrently(prefix, entries)
+	}
+	for _, entry := range entries {
+		if err := enc.encode(enc.joinKey(prefix, entry.key), entry.value, fromMapEntry, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// methodFree reports whether neither typ nor any of the types that values
+// of it may hold have methods, so that encoding them can't call user code.
+// Interfaces could hold anything. seen breaks the cycles of recursive types.
+func methodFree(typ reflect.Type, seen map[reflect.Type]bool) bool {
+	if seen[typ] {
+		return true
+	}
+	if typ.Kind() == reflect.Interface || typ.NumMethod() > 0 {
+		return false
+	}
+	if typ.Kind() != reflect.Ptr && reflect.PtrTo(typ).NumMethod() > 0 {
+		return false
+	}
+	if seen == nil {
+		seen = make(map[reflect.Type]bool)
+	}
+	seen[typ] = true
+
+	switch typ.Kind() {
+	case reflect.Ptr, reflect.Array, reflect.Slice:
+		return methodFree(typ.Elem(), seen)
+	case reflect.Map:
+		return methodFree(typ.Key(), seen) && methodFree(typ.Elem(), seen)
+	case reflect.Struct:
+		for i := 0; i < typ.NumField(); i++ {
+			if !methodFree(typ.Field(i).Type, seen) {
+				return false
 			}
+		}
+	}
+	return true
+}
 
-		case reflect.Struct:
-			n := fieldVal.NumField()
-			typ := fieldVal.Type()
-			for i := 0; i < n; i++ {
-				ffield := fieldVal.Field(i)
-				fTyp := typ.Field(i)
-				if unexportedField(fTyp.Name) {
-					continue
-				}
-				tag, omitempty, ignore := jsonTag(fTyp)
-				if ignore {
-					continue
-				}
-				keyname := strings.Join([]string{parentTag, tag}, ".")
-				fIface := ffield.Interface()
-				innerValueMap, err := ToURLValues(fIface)
-				if err == nil && innerValueMap == nil {
-					zeroValue := reflect.Zero(ffield.Type())
-					blank := isBlank(fIface) || isBlankReflectValue(ffield) || reflect.DeepEqual(zeroValue.Interface(), fIface)
-					if omitempty && blank {
-						continue
-					}
-					if !blank {
-						fullMap.Add(keyname, fmt.Sprintf("%v", fIface))
-					}
-					continue
-				}
+// encodeMapEntriesConcurrently splits entries into one contiguous chunk per
+// worker and encodes each chunk with its own encoder. The chunks are then
+// appended in order, so the pairs and any error returned are exactly those
+// of the serial path.
+func (enc *urlEncoder) encodeMapEntriesConcurrently(prefix string, entries []mapEntry) error {
+	workers := enc.workers
+	if workers > len(entries) {
+		workers = len(entries)
+	}
+	chunkSize := (len(entries) + workers - 1) / workers
+	chunks := make([]*urlEncoder, 0, workers)
+	errs := make([]error, workers)
 
-				for key, innerValueList := range innerValueMap {
-					keyname := strings.Join([]string{keyname, key}, ".")
-					fullMap[keyname] = append(fullMap[keyname], innerValueList...)
+	var wg sync.WaitGroup
+	for start := 0; start < len(entries); start += chunkSize {
+		end := start + chunkSize
+		if end > len(entries) {
+			end = len(entries)
+		}
+		chunk := &urlEncoder{opts: enc.opts}
+		chunks = append(chunks, chunk)
+
+		wg.Add(1)
+		go func(i int, chunk *urlEncoder, entries []mapEntry) {
+			defer wg.Done()
+			for _, entry := range entries {
+				if err := chunk.encode(chunk.joinKey(prefix, entry.key), entry.value, fromMapEntry, nil); err != nil {
+					errs[i] = err
+					return
 				}
 			}
+		}(len(chunks)-1, chunk, entries[start:end])
+	}
+	wg.Wait()
 
-		default:
-			aIface := fieldVal.Interface()
-			zeroValue := reflect.Zero(fieldVal.Type())
-			blank := isBlank(aIface) || isBlankReflectValue(fieldVal) || reflect.DeepEqual(zeroValue.Interface(), aIface)
-			if !blank {
-				keyname := parentTag
-				fullMap[keyname] = append(fullMap[keyname], fmt.Sprintf("%v", aIface))
-			}
+	for i, chunk := range chunks {
+		if errs[i] != nil {
+			return errs[i]
 		}
+		enc.pairs = append(enc.pairs, chunk.pairs...)
 	}
-
-	return fullMap, nil
+	return nil
 }
 
-func toURLValuesForSlice(v interface{}) (url.Values, error) {
-	val := reflect.ValueOf(v)
-	n := val.Len()
-	finalValues := make(url.Values)
-	if val.Len() < 1 {
-		return nil, nil
+// isBasicMap reports whether typ is a map from string to a builtin string,
+// boolean or numeric type, such as map[string]string or map[string]int.
+// None of the special cases for keys and values apply to those maps, as
+// their types have no methods, so they can be encoded directly unless
+// the EnumNames or IsBlankFunc options could apply to their values.
+func (enc *urlEncoder) isBasicMap(typ reflect.Type) bool {
+	if typ.Key() != stringType {
+		return false
+	}
+	elem := typ.Elem()
+	if elem.PkgPath() != "" || elem.Name() == "" {
+		return false
+	}
+	if _, ok := enc.opts.EnumNames[elem]; ok || enc.opts.IsBlankFunc != nil {
+		return false
 	}
+	return elem.Kind() == reflect.String || elem.Kind() == reflect.Bool || isNumberKind(elem.Kind())
+}
 
-	sliceValues := val.Slice(0, val.Len())
-	for i := 0; i < n; i++ {
-		ithVal := sliceValues.Index(i)
-		iface := ithVal.Interface()
-		// Goal here is to recombine them into
-		// {0: url.Values}
-		retr, _ := ToURLValues(iface)
-		if len(retr) > 0 {
-			key := fmt.Sprintf("%d", i)
-			finalValues[key] = append(finalValues[key], retr.Encode())
+// encodeBasicMap is the fast path of encodeMap for the maps that isBasicMap
+// reports, producing the same output as encodeMapEntries without the
+// reflection and allocations that encoding each entry generically takes.
+func (enc *urlEncoder) encodeBasicMap(prefix string, v reflect.Value) error {
+	entries := make([]KeyValue, 0, v.Len())
+	iter := v.MapRange()
+	for iter.Next() {
+		value := iter.Value()
+		var str string
+		switch value.Kind() {
+		case reflect.String:
+			str = value.String()
+		case reflect.Bool:
+			if !value.Bool() {
+				// Left out as blank like for any map entry.
+				continue
+			}
+			str = enc.opts.BoolFormat.format(true)
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			str = strconv.FormatInt(value.Int(), 10)
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			str = strconv.FormatUint(value.Uint(), 10)
+		case reflect.Float32, reflect.Float64:
+			skip, err := enc.opts.checkFinite(enc.joinKey(prefix, iter.Key().String()), value)
+			if err != nil {
+				return err
+			}
+			if !skip {
+				str = strconv.FormatFloat(value.Float(), 'g', -1, value.Type().Bits())
+			}
+		}
+		if str != "" {
+			entries = append(entries, KeyValue{Key: iter.Key().String(), Value: str})
 		}
 	}
-
-	return finalValues, nil
+	if !enc.opts.UnsortedMapKeys {
+		sort.Slice(entries, func(i, j int) bool {
+			return entries[i].Key < entries[j].Key
+		})
+	}
+	for _, entry := range entries {
+		enc.add(enc.joinKey(prefix, entry.Key), entry.Value)
+	}
+	return nil
 }
 
-func toURLValuesForMap(v interface{}) (url.Values, error) {
-	val := reflect.ValueOf(v)
-	keys := val.MapKeys()
+func (enc *urlEncoder) encodeSlice(key string, v reflect.Value) error {
+	for i := 0; i < v.Len(); i++ {
+		elem := v.Index(i)
+		if isNilReflectValue(elem) {
+			continue
+		}
 
-	fullMap := make(url.Values)
-	for _, key := range keys {
-		value := val.MapIndex(key)
-		vIface := value.Interface()
-		keyname := fmt.Sprintf("%v", key)
-		innerValueMap, err := ToURLValues(vIface)
-		if err == nil && innerValueMap == nil {
-			if !isBlankReflectValue(value) && !isBlank(vIface) {
-				fullMap.Add(keyname, fmt.Sprintf("%v", vIface))
+		if isSliceValue(elem) {
+			// Nested slices are emitted under their index, with a
+			// slice of scalars becoming a single comma separated
+			// value e.g. [][]int{{1, 2}, {3}} => "0=1,2&1=3".
+			rowKey := enc.joinKey(key, enc.index(i))
+			row, ok, err := enc.joinScalars(rowKey, elem)
+			if err != nil {
+				return err
+			}
+			if ok {
+				if row != "" {
+					enc.add(rowKey, row)
+				}
+				continue
+			}
+			if err := enc.encodeSlice(rowKey, elem); err != nil {
+				return err
 			}
 			continue
 		}
 
-		for key, innerValueList := range innerValueMap {
-			innerKeyname := strings.Join([]string{keyname, key}, ".")
-			fullMap[innerKeyname] = append(fullMap[innerKeyname], innerValueList...)
+		if isCompositeValue(elem) && !enc.isScalarValue(elem) {
+			// Structs and maps are flattened under their index
+			// e.g. "addresses.0.city=...&addresses.1.city=...".
+			if err := enc.encode(enc.joinKey(key, enc.index(i)), elem, fromSliceElem, nil); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// Scalars are repeated under the same key, except at the top
+		// level where there is no key, or only the Prefix, so the index
+		// is used as for other elements.
+		elemKey := key
+		if enc.topLevel(key) {
+			elemKey = enc.joinKey(key, enc.index(i))
+		}
+		if err := enc.encode(elemKey, elem, fromSliceElem, nil); err != nil {
+			return err
 		}
 	}
+	return nil
+}
 
-	return fullMap, nil
+// joinScalars returns the comma separated values of the slice v,
+// or false if any of its elements aren't scalars.
+func (enc *urlEncoder) joinScalars(key string, v reflect.Value) (string, bool, error) {
+	v = indirectValue(v)
+	values := make([]string, 0, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		elem := indirectValue(v.Index(i))
+		if !elem.IsValid() {
+			continue
+		}
+		if inner, ok := sqlNullValue(elem); ok {
+			if !inner.IsValid() {
+				values = append(values, "")
+				continue
+			}
+			elem = inner
+		}
+		if value, ok := enc.scalarString(elem); ok {
+			values = append(values, value)
+			continue
+		}
+		if isSliceValue(elem) || isCompositeValue(elem) {
+			return "", false, nil
+		}
+		skip, err := enc.opts.checkFinite(key, elem)
+		if err != nil {
+			return "", false, err
+		}
+		if !skip {
+			values = append(values, enc.opts.formatValue(elem))
+		}
+	}
+	return strings.Join(values, ","), true, nil
+}
+
+// addJSON adds the JSON encoding of v as a single value for key.
+func (enc *urlEncoder) addJSON(key string, v reflect.Value) error {
+	blob, err := json.Marshal(v.Interface())
+	if err != nil {
+		return err
+	}
+	enc.add(key, string(blob))
+	return nil
+}
+
+// URLKeyer is implemented by map key types that control the
+// string form of their keys in query strings, e.g. to format
+// a composite key as "region:zone".
+type URLKeyer interface {
+	URLKey() string
+}
+
+// mapKeyString returns the string form of a map key. It prefers URLKeyer,
+// then encoding.TextMarshaler, then fmt.Stringer and lastly falls back to "%v".
+func mapKeyString(key reflect.Value) (string, error) {
+	if key.Kind() == reflect.Interface && !key.IsNil() {
+		key = key.Elem()
+	}
+	if k, ok := key.Interface().(URLKeyer); ok {
+		return k.URLKey(), nil
+	}
+	if m, ok := textMarshalerOf(key); ok {
+		text, err := m.MarshalText()
+		if err != nil {
+			return "", err
+		}
+		return string(text), nil
+	}
+	switch k := key.Interface().(type) {
+	case fmt.Stringer:
+		return k.String(), nil
+	default:
+		return fmt.Sprintf("%v", k), nil
+	}
 }
 
 // isBlank returns true if a value will leave a value blank in a URL Query string
@@ -223,25 +1206,397 @@ func isBlankReflectValue(v reflect.Value) bool {
 	}
 }
 
+// formatValue returns the query string form of a leaf value.
+func (opts *URLValuesOptions) formatValue(v reflect.Value) string {
+	if v.Kind() == reflect.Interface && !v.IsNil() {
+		v = v.Elem()
+	}
+	if names, ok := opts.EnumNames[v.Type()]; ok {
+		var n int64
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			n = v.Int()
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			n = int64(v.Uint())
+		}
+		if name, ok := names[n]; ok {
+			return name
+		}
+	}
+	switch v.Kind() {
+	case reflect.Bool:
+		return opts.BoolFormat.format(v.Bool())
+	case reflect.Array, reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			b := make([]byte, v.Len())
+			reflect.Copy(reflect.ValueOf(b), v)
+			return opts.ByteSliceEncoding.format(b)
+		}
+	}
+	return fmt.Sprintf("%v", v.Interface())
+}
+
+// isBlankValue reports whether v should be left out of the query
+// string. Zero values are considered blank except for numbers which
+// are only blank when omitempty is set or KeepZeroNumbers is unset.
+func (opts *URLValuesOptions) isBlankValue(v reflect.Value, omitempty bool) bool {
+	iface := v.Interface()
+	if isBlank(iface) || isBlankReflectValue(v) {
+		return true
+	}
+	if !reflect.DeepEqual(reflect.Zero(v.Type()).Interface(), iface) {
+		return false
+	}
+	if isNumberKind(v.Kind()) {
+		return omitempty || !opts.KeepZeroNumbers
+	}
+	return true
+}
+
+func isFloatKind(kind reflect.Kind) bool {
+	return kind == reflect.Float32 || kind == reflect.Float64
+}
+
+// checkFinite applies the NonFiniteFloats policy to v if it is a NaN or
+// infinite float, reporting whether it should be skipped or failing.
+func (opts *URLValuesOptions) checkFinite(key string, v reflect.Value) (skip bool, err error) {
+	if !isFloatKind(v.Kind()) {
+		return false, nil
+	}
+	if f := v.Float(); !math.IsNaN(f) && !math.IsInf(f, 0) {
+		return false, nil
+	}
+	switch opts.NonFiniteFloats {
+	case NonFiniteFloatSkip:
+		return true, nil
+	case NonFiniteFloatAsString:
+		return false, nil
+	default:
+		return false, fmt.Errorf("%s: unsupported float value %v", key, v.Float())
+	}
+}
+
+func isNumberKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return true
+	default:
+		return false
+	}
+}
+
+var (
+	ipType          = reflect.TypeOf(net.IP(nil))
+	mailAddressType = reflect.TypeOf(mail.Address{})
+	rawMessageType  = reflect.TypeOf(json.RawMessage(nil))
+	timeType        = reflect.TypeOf(time.Time{})
+	stringType      = reflect.TypeOf("")
+	nullTimeType    = reflect.TypeOf(sql.NullTime{})
+
+	// sqlNullTypes are the sql.Null* types whose inner value is
+	// emitted when Valid, by way of their driver.Valuer method.
+	sqlNullTypes = map[reflect.Type]bool{
+		reflect.TypeOf(sql.NullBool{}):    true,
+		reflect.TypeOf(sql.NullByte{}):    true,
+		reflect.TypeOf(sql.NullFloat64{}): true,
+		reflect.TypeOf(sql.NullInt16{}):   true,
+		reflect.TypeOf(sql.NullInt32{}):   true,
+		reflect.TypeOf(sql.NullInt64{}):   true,
+		reflect.TypeOf(sql.NullString{}):  true,
+	}
+)
+
+// scalarString returns the single value that v is emitted as if its type
+// is one that shouldn't be flattened or formatted by reflecting into it.
+// ok is false for all other types. An empty value means that v is blank.
+func (enc *urlEncoder) scalarString(v reflect.Value) (value string, ok bool) {
+	switch v.Type() {
+	case ipType:
+		ip := v.Interface().(net.IP)
+		if len(ip) == 0 {
+			return "", true
+		}
+		return ip.String(), true
+
+	case mailAddressType:
+		addr := v.Interface().(mail.Address)
+		if addr.Address == "" {
+			return "", true
+		}
+		if addr.Name == "" {
+			// Avoid the angle brackets that mail.Address.String adds.
+			return addr.Address, true
+		}
+		return addr.String(), true
+
+	case timeType:
+		t := v.Interface().(time.Time)
+		if t.IsZero() {
+			return "", true
+		}
+		return enc.opts.formatTime(t), true
+
+	case nullTimeType:
+		nt := v.Interface().(sql.NullTime)
+		if !nt.Valid || nt.Time.IsZero() {
+			return "", true
+		}
+		return enc.opts.formatTime(nt.Time), true
+
+	case rawMessageType:
+		// Pre-serialized JSON is passed through as is, only
+		// compacted when valid so that it doesn't carry whitespace.
+		raw := v.Interface().(json.RawMessage)
+		var buf bytes.Buffer
+		if err := json.Compact(&buf, raw); err == nil {
+			return buf.String(), true
+		}
+		return string(raw), true
+	}
+	return "", false
+}
+
+// sqlNullValue returns the inner value of v if it is one of sqlNullTypes,
+// which is the zero Value if v isn't Valid. ok is false for other types.
+func sqlNullValue(v reflect.Value) (inner reflect.Value, ok bool) {
+	if !sqlNullTypes[v.Type()] {
+		return reflect.Value{}, false
+	}
+	// Value never fails for the sql.Null* types and
+	// returns nil when they aren't Valid.
+	value, _ := v.Interface().(driver.Valuer).Value()
+	if value == nil {
+		return reflect.Value{}, true
+	}
+	return reflect.ValueOf(value), true
+}
+
+// isScalarValue reports whether v, or the value that it points
+// to, is of a type that scalarString emits as a single value.
+func (enc *urlEncoder) isScalarValue(v reflect.Value) bool {
+	v = indirectValue(v)
+	if !v.IsValid() {
+		return false
+	}
+	if _, ok := sqlNullValue(v); ok {
+		return true
+	}
+	if _, ok := enc.scalarString(v); ok {
+		return true
+	}
+	_, ok := textMarshalerOf(v)
+	return ok
+}
+
+var textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+// textMarshalerOf returns v as an encoding.TextMarshaler if either v or a
+// pointer to it implements the interface. For the latter, v's address is used
+// if it has one and otherwise a copy of v is made, since values from maps,
+// for example, aren't addressable.
+func textMarshalerOf(v reflect.Value) (encoding.TextMarshaler, bool) {
+	if v.Type().Implements(textMarshalerType) {
+		if v.Kind() == reflect.Ptr && v.IsNil() {
+			return nil, false
+		}
+		return v.Interface().(encoding.TextMarshaler), true
+	}
+	if !reflect.PtrTo(v.Type()).Implements(textMarshalerType) {
+		return nil, false
+	}
+	if v.CanAddr() {
+		return v.Addr().Interface().(encoding.TextMarshaler), true
+	}
+	ptr := reflect.New(v.Type())
+	ptr.Elem().Set(v)
+	return ptr.Interface().(encoding.TextMarshaler), true
+}
+
+// indirectValue dereferences pointers and interfaces until it reaches
+// a concrete value. It returns the zero Value if it encounters a nil.
+func indirectValue(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+// isSliceValue reports whether v is a slice or array, or a pointer or
+// interface holding one. Byte slices aren't included since they're
+// emitted as a single value.
+func isSliceValue(v reflect.Value) bool {
+	v = indirectValue(v)
+	switch v.Kind() {
+	case reflect.Array, reflect.Slice:
+		return v.Type().Elem().Kind() != reflect.Uint8
+	default:
+		return false
+	}
+}
+
+// isCompositeValue reports whether v is a struct or a map,
+// or a pointer or interface holding one.
+func isCompositeValue(v reflect.Value) bool {
+	v = indirectValue(v)
+	return v.Kind() == reflect.Struct || v.Kind() == reflect.Map
+}
+
+func isNilReflectValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice:
+		return v.IsNil()
+	default:
+		return false
+	}
+}
+
 var errInvalidValue = errors.New("invalid value")
 
-func jsonTag(v reflect.StructField) (tag string, omitempty, ignore bool) {
+// tagOptions are the comma separated options that
+// follow the name in a `url` or `json` struct tag.
+type tagOptions []string
+
+func (opts tagOptions) has(name string) bool {
+	for _, opt := range opts {
+		if opt == name {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldTag returns the key name and options for a struct field.
+// The `url` struct tag takes precedence over the `json` struct tag
+// so that query string names can differ from JSON body names. An
+// empty name in the `url` tag falls back to the `json` name.
+//
+// Besides the options understood by encoding/json, the `url` tag
+// supports the "json" option which emits the field as a single JSON
+// encoded value instead of flattening it into dotted keys, e.g.
+// `url:"config,json"`, and the "emitnil" option which emits an
+// empty value for a nil pointer instead of omitting it, e.g.
+// `url:"ref,emitnil"` produces "ref=". The "flatten" option, or its
+// alias "inline", emits the entries of a map field at the level of
+// the field itself without the field's name as a prefix, so that
+// `url:"meta,flatten"` produces "a=1" rather than "meta.a=1". The
+// "required" option makes encoding fail if the field is blank.
+func fieldTag(v reflect.StructField) (tag string, opts tagOptions, ignore bool) {
+	urlTag, hasURLTag := v.Tag.Lookup("url")
+	jsonName, jsonOpts, jsonIgnore := jsonTag(v)
+	if !hasURLTag {
+		return jsonName, jsonOpts, jsonIgnore
+	}
+
+	splits := strings.Split(urlTag, ",")
+	tag, opts = splits[0], tagOptions(splits[1:])
+	switch tag {
+	case "-":
+		return tag, opts, true
+	case "":
+		if jsonIgnore {
+			tag = v.Name
+		} else {
+			tag = jsonName
+		}
+	}
+	return tag, opts, opts.has("-")
+}
+
+func jsonTag(v reflect.StructField) (tag string, opts tagOptions, ignore bool) {
 	tag = v.Tag.Get("json")
 	if tag == "" {
-		return v.Name, false, false
+		return v.Name, nil, false
 	}
 
 	splits := strings.Split(tag, ",")
-	if len(splits) == 0 {
-		return "", false, false
+	tag, opts = splits[0], tagOptions(splits[1:])
+	if tag == "" {
+		tag = v.Name
+	}
+	return tag, opts, opts.has("-") || tag == "-"
+}
+
+// flattenEmbedded reports whether the fields of an embedded
+// struct should be promoted into its parent, which is what
+// encoding/json does for embedded structs given no tag name.
+// An embedded interface is promoted if it holds a struct, or
+// a pointer to one, as there is no other name for its fields.
+func flattenEmbedded(field reflect.StructField, v reflect.Value) bool {
+	if !field.Anonymous {
+		return false
+	}
+	for _, key := range []string{"url", "json"} {
+		if name := strings.Split(field.Tag.Get(key), ",")[0]; name != "" {
+			return false
+		}
+	}
+	typ := field.Type
+	if typ.Kind() == reflect.Interface {
+		return indirectValue(v).Kind() == reflect.Struct
+	}
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	return typ.Kind() == reflect.Struct
+}
+
+// unexportedField reports whether field is unexported, which reflect
+// only lets be read, but not be set or passed to Interface. Checking
+// PkgPath also covers names that start with "_" or a non-ASCII letter.
+func unexportedField(field reflect.StructField) bool {
+	return field.PkgPath != ""
+}
+
+// promotedUnexported reports whether field is an untagged embedded struct,
+// or pointer to one, of an unexported type, whose exported fields are still
+// promoted. Only the field itself is read-only, not the fields of its type.
+func promotedUnexported(field reflect.StructField, v reflect.Value) bool {
+	if field.Type.Kind() == reflect.Interface || !flattenEmbedded(field, v) {
+		return false
 	}
-	tag, instrs := splits[0], splits[1:]
-	instrIndex := make(map[string]bool)
-	for _, instr := range instrs {
-		instrIndex[instr] = true
+	_, _, ignore := fieldTag(field)
+	return !ignore
+}
+
+// isRequiredField reports whether field was tagged as required with either
+// the "required" option of the `url` tag, e.g. `url:"id,required"`, or with
+// a validator style `validate:"required"` tag.
+func isRequiredField(field reflect.StructField, opts tagOptions) bool {
+	if opts.has("required") {
+		return true
+	}
+	validate, ok := field.Tag.Lookup("validate")
+	return ok && tagOptions(strings.Split(validate, ",")).has("required")
+}
+
+// isEmptyFieldValue reports whether v fails a required check: nil pointers and
+// interfaces, empty strings, slices, maps and arrays and otherwise zero values.
+// Like with validators, a non-nil pointer is set even if it points to a zero.
+func isEmptyFieldValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		return v.IsNil()
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	default:
+		return v.IsZero()
 	}
+}
 
-	_, omitempty = instrIndex["omitempty"]
-	_, ignore = instrIndex["-"]
-	return tag, omitempty, ignore || tag == "-"
+// flattenTagged reports whether a map or struct field was tagged with
+// the "flatten" or "inline" options to promote its entries into its parent.
+func flattenTagged(field reflect.StructField, opts tagOptions) bool {
+	if !opts.has("flatten") && !opts.has("inline") {
+		return false
+	}
+	typ := field.Type
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	return typ.Kind() == reflect.Map || typ.Kind() == reflect.Struct
 }
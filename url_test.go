@@ -0,0 +1,276 @@
+package otils
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSortMapKeys(t *testing.T) {
+	v := map[string]int{"delta": 4, "alpha": 1, "charlie": 3, "bravo": 2, "echo": 5}
+	want := []KeyValue{
+		{Key: "alpha", Value: "1"},
+		{Key: "bravo", Value: "2"},
+		{Key: "charlie", Value: "3"},
+		{Key: "delta", Value: "4"},
+		{Key: "echo", Value: "5"},
+	}
+
+	tests := []struct {
+		name string
+		opts *URLValuesOptions
+	}{
+		{"default options", nil},
+		{"zero options", &URLValuesOptions{}},
+		{"other options set", &URLValuesOptions{BoolFormat: BoolYesNo, MaxKeys: 10}},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			// Repeat to make it unlikely that map iteration order matched by chance.
+			for i := 0; i < 10; i++ {
+				got, err := toURLValuePairs(v, tc.opts)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !reflect.DeepEqual(got, want) {
+					t.Fatalf("unexpected result, want: %v, got: %v", want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestEncodeBasicMap(t *testing.T) {
+	tests := []struct {
+		name string
+		v    interface{}
+	}{
+		{"strings", map[string]string{"b": "2", "a": "1", "blank": "", "space": "a b"}},
+		{"ints", map[string]int{"zero": 0, "neg": -3, "big": 1 << 40}},
+		{"int8s", map[string]int8{"min": -128, "max": 127}},
+		{"uints", map[string]uint64{"max": ^uint64(0), "zero": 0}},
+		{"float32s", map[string]float32{"third": 1.0 / 3, "half": 0.5}},
+		{"float64s", map[string]float64{"third": 1.0 / 3, "e": 2.718281828459045, "tiny": 1e-300}},
+		{"bools", map[string]bool{"yes": true, "no": false}},
+	}
+
+	opts := []*URLValuesOptions{
+		{},
+		{BoolFormat: BoolYesNo},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			for _, opts := range opts {
+				v := reflect.ValueOf(tc.v)
+				generic := &urlEncoder{opts: opts}
+				if !generic.isBasicMap(v.Type()) {
+					t.Fatalf("expected %T to take the fast path", tc.v)
+				}
+				if err := generic.encodeMapEntries("meta", v); err != nil {
+					t.Fatal(err)
+				}
+				fast := &urlEncoder{opts: opts}
+				if err := fast.encodeBasicMap("meta", v); err != nil {
+					t.Fatal(err)
+				}
+				if !reflect.DeepEqual(fast.pairs, generic.pairs) {
+					t.Errorf("unexpected result, want: %v, got: %v", generic.pairs, fast.pairs)
+				}
+			}
+		})
+	}
+}
+
+func TestEncodeBasicMapNonFiniteFloats(t *testing.T) {
+	v := reflect.ValueOf(map[string]float64{"nan": math.NaN(), "inf": math.Inf(-1), "one": 1})
+	tests := []struct {
+		name    string
+		policy  NonFiniteFloatPolicy
+		want    []KeyValue
+		wantErr bool
+	}{
+		{"error", NonFiniteFloatError, nil, true},
+		{"skip", NonFiniteFloatSkip, []KeyValue{{Key: "meta.one", Value: "1"}}, false},
+		{
+			"as string",
+			NonFiniteFloatAsString,
+			[]KeyValue{{Key: "meta.inf", Value: "-Inf"}, {Key: "meta.nan", Value: "NaN"}, {Key: "meta.one", Value: "1"}},
+			false,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			opts := &URLValuesOptions{NonFiniteFloats: tc.policy}
+			generic := &urlEncoder{opts: opts}
+			genericErr := generic.encodeMapEntries("meta", v)
+			fast := &urlEncoder{opts: opts}
+			fastErr := fast.encodeBasicMap("meta", v)
+			if (genericErr != nil) != tc.wantErr || (fastErr != nil) != tc.wantErr {
+				t.Fatalf("unexpected errors, want: %v, got: %v and %v", tc.wantErr, genericErr, fastErr)
+			}
+			if tc.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(generic.pairs, tc.want) || !reflect.DeepEqual(fast.pairs, tc.want) {
+				t.Errorf("unexpected result, want: %v, got: %v and %v", tc.want, generic.pairs, fast.pairs)
+			}
+		})
+	}
+}
+
+func TestIsBasicMap(t *testing.T) {
+	type name string
+	type level int
+	tests := []struct {
+		name     string
+		v        interface{}
+		opts     *URLValuesOptions
+		expected bool
+	}{
+		{"string values", map[string]string{}, &URLValuesOptions{}, true},
+		{"int values", map[string]int{}, &URLValuesOptions{}, true},
+		{"named keys", map[name]string{}, &URLValuesOptions{}, false},
+		{"named values", map[string]level{}, &URLValuesOptions{}, false},
+		{"interface values", map[string]interface{}{}, &URLValuesOptions{}, false},
+		{"slice values", map[string][]string{}, &URLValuesOptions{}, false},
+		{
+			"enum values",
+			map[string]int{},
+			&URLValuesOptions{EnumNames: map[reflect.Type]map[int64]string{reflect.TypeOf(0): {1: "one"}}},
+			false,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			enc := &urlEncoder{opts: tc.opts}
+			if got := enc.isBasicMap(reflect.TypeOf(tc.v)); got != tc.expected {
+				t.Errorf("unexpected result, want: %v, got: %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+type benchEntry struct {
+	Name  string            `json:"name"`
+	Count int               `json:"count"`
+	Tags  []string          `json:"tags"`
+	Attrs map[string]string `json:"attrs"`
+}
+
+func largeEntryMap(n int) map[string]benchEntry {
+	m := make(map[string]benchEntry, n)
+	for i := 0; i < n; i++ {
+		m[fmt.Sprintf("entry-%d", i)] = benchEntry{
+			Name:  fmt.Sprintf("name %d", i),
+			Count: i,
+			Tags:  []string{"a", "b"},
+			Attrs: map[string]string{"k": fmt.Sprintf("v%d", i)},
+		}
+	}
+	return m
+}
+
+func TestEncodeMapEntriesConcurrently(t *testing.T) {
+	type required struct {
+		ID string `url:"id,required"`
+	}
+	withBlank := make(map[string]required, 2*concurrentMapMinEntries)
+	for i := 0; i < 2*concurrentMapMinEntries; i++ {
+		withBlank[fmt.Sprintf("entry-%04d", i)] = required{ID: fmt.Sprint(i)}
+	}
+	withBlank["entry-0100"] = required{}
+	withBlank["entry-0400"] = required{}
+
+	tests := []struct {
+		name string
+		v    interface{}
+		opts *URLValuesOptions
+	}{
+		{"sorted", largeEntryMap(3*concurrentMapMinEntries + 1), nil},
+		{"prefixed", largeEntryMap(concurrentMapMinEntries), &URLValuesOptions{Prefix: "p"}},
+		{"below threshold", largeEntryMap(concurrentMapMinEntries - 1), nil},
+		{"first error wins", withBlank, nil},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			want, wantErr := encodeURLValuePairs(tc.v, tc.opts, 1)
+			for _, workers := range []int{2, 3, 8} {
+				got, err := encodeURLValuePairs(tc.v, tc.opts, workers)
+				if fmt.Sprint(err) != fmt.Sprint(wantErr) {
+					t.Fatalf("workers=%d: unexpected error, want: %v, got: %v", workers, wantErr, err)
+				}
+				if !reflect.DeepEqual(got, want) {
+					t.Fatalf("workers=%d: output differs from the serial path", workers)
+				}
+			}
+		})
+	}
+}
+
+type labelText string
+
+func (l labelText) MarshalText() ([]byte, error) { return []byte("label:" + l), nil }
+
+type node struct {
+	Name     string  `json:"name"`
+	Children []*node `json:"children"`
+}
+
+func TestMethodFree(t *testing.T) {
+	tests := []struct {
+		name     string
+		v        interface{}
+		expected bool
+	}{
+		{"builtin", 0, true},
+		{"struct of builtins", benchEntry{}, true},
+		{"recursive", node{}, true},
+		{"method", labelText(""), false},
+		{"pointer receiver", bytes.Buffer{}, false},
+		{"nested method", struct{ Labels map[string]labelText }{}, false},
+		{"time", struct{ At time.Time }{}, false},
+		{"interface", []interface{}{}, false},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if got := methodFree(reflect.TypeOf(tc.v), nil); got != tc.expected {
+				t.Errorf("unexpected result, want: %v, got: %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func BenchmarkEncodeLargeMap(b *testing.B) {
+	v := largeEntryMap(10000)
+	for _, workers := range []int{1, 4} {
+		workers := workers
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := encodeURLValuePairs(v, nil, workers); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
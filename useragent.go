@@ -0,0 +1,16 @@
+package otils
+
+import "regexp"
+
+// mobileUserAgentRegexp matches the tokens that
+// common mobile browsers put in their user agents.
+var mobileUserAgentRegexp = regexp.MustCompile(`(?i)mobile|android|iphone|ipad|ipod|blackberry|bb10|windows phone|iemobile|opera mini|silk/|kindle|webos`)
+
+// IsMobileUserAgent reports whether ua looks like the user agent of a
+// mobile device, such as a phone or tablet. It is a coarse heuristic for
+// light server-side adaptation that only checks for common tokens like
+// "Mobile", "Android" and "iPhone", so it can be fooled and misses devices
+// that present desktop user agents, as iPads do by default since iPadOS 13.
+func IsMobileUserAgent(ua string) bool {
+	return mobileUserAgentRegexp.MatchString(ua)
+}